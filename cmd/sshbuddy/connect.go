@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/internal/target"
+	"sshbuddy/internal/tui"
+	"sshbuddy/pkg/models"
+)
+
+// runConnect implements "sshbuddy connect": it connects to a host by alias
+// without the TUI, in this terminal or in a new window. Connection targets
+// run it in the windows they open.
+func runConnect(args []string) error {
+	fs := flag.NewFlagSet("connect", flag.ContinueOnError)
+	source := fs.String("source", "", "the host's source or source kind, when several sources have the alias")
+	targetName := fs.String("target", "", "where to open the session: "+strings.Join(models.ConnectTargets, ", ")+" (default: from the config)")
+	hold := fs.Bool("hold", false, "if the connection fails, wait for Enter before exiting")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sshbuddy connect [-source name] [-target name] [-hold] <alias>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected the alias of one host")
+	}
+	if *targetName != "" && !slices.Contains(models.ConnectTargets, *targetName) {
+		return fmt.Errorf("unknown target %q (valid: %s)", *targetName, strings.Join(models.ConnectTargets, ", "))
+	}
+
+	cfg, hosts, err := loadConfigAndHosts()
+	if err != nil {
+		return err
+	}
+	host, err := findHost(hosts, fs.Arg(0), *source)
+	if err != nil {
+		return err
+	}
+
+	name := *targetName
+	if name == "" {
+		name = target.Default(cfg.Connect)
+	}
+	if name != models.TargetTerminal {
+		return target.Open(cfg.Connect, name, host)
+	}
+
+	fmt.Printf("Connecting to %s...\n", tui.HostDestination(host))
+	err = tui.Connect(cfg, host)
+
+	// ssh exits with 255 when it can't connect, otherwise with the status of
+	// the session, which is passed on like ssh itself would. A failed hook's
+	// status is part of its error instead.
+	exitErr, isExit := err.(*exec.ExitError)
+	if err != nil && *hold && (!isExit || exitErr.ExitCode() == 255) {
+		fmt.Fprintf(os.Stderr, "Error: %v\nPress Enter to close.\n", err)
+		bufio.NewReader(os.Stdin).ReadString('\n')
+	}
+	if isExit {
+		return exitStatus(exitErr.ExitCode())
+	}
+	return err
+}
+
+// findHost returns the host with alias, from source if it isn't empty
+func findHost(hosts []models.Host, alias, source string) (models.Host, error) {
+	for _, host := range hosts {
+		if host.Alias != alias {
+			continue
+		}
+		if source != "" && config.SourceRank(host.Source, []string{source}) != 0 {
+			continue
+		}
+		return host, nil
+	}
+	if source != "" {
+		return models.Host{}, fmt.Errorf("no host %q in %s", alias, source)
+	}
+	return models.Host{}, fmt.Errorf("no host %q", alias)
+}
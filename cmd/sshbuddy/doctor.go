@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sshbuddy/internal/doctor"
+)
+
+// runDoctor checks the setup and prints a report. It fails if any check failed.
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	offline := fs.Bool("offline", false, "skip checks that need the network (Termix servers)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: sshbuddy doctor [-offline]\n\nChecks the config, host sources, ssh and the ssh agent, and prints a report\nto paste into an issue.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	report := doctor.Run(doctor.Options{Version: version, Offline: *offline})
+	if err := report.Write(os.Stdout); err != nil {
+		return err
+	}
+	if failed := report.Count(doctor.Fail); failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
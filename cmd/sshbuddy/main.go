@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"sshbuddy/internal/askpass"
+	"sshbuddy/internal/logging"
 	"sshbuddy/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -10,28 +14,95 @@ import (
 
 var version = "dev"
 
+// exitStatus is returned by subcommands to exit with a status of their own,
+// such as that of the SSH session, without printing an error
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run runs sshbuddy and returns its exit status. Deferred calls such as
+// closing the log only run if nothing in here calls os.Exit.
+func run() int {
+	// When ssh runs us as its SSH_ASKPASS helper, answer the prompt and exit
+	if askpass.IsHelper() {
+		return askpass.RunHelper(os.Args[1:])
+	}
+
+	// --debug works anywhere on the command line, for the TUI and subcommands alike
+	debug := logging.DebugRequested()
+	args := os.Args[:1]
+	for _, arg := range os.Args[1:] {
+		if arg == "--debug" || arg == "-debug" {
+			debug = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+
+	// Logs go to a file only; the terminal belongs to the TUI
+	closeLog, _ := logging.Init(debug)
+	defer closeLog()
+
 	// Handle version flag
 	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
 		fmt.Printf("sshbuddy version %s\n", version)
-		os.Exit(0)
+		return 0
 	}
+
+	// Subcommands that run without the TUI
+	if len(os.Args) > 1 {
+		var command func([]string) error
+		switch os.Args[1] {
+		case "export":
+			command = runExport
+		case "import":
+			command = runImport
+		case "doctor":
+			command = runDoctor
+		case "connect":
+			command = runConnect
+		}
+		if command != nil {
+			err := command(os.Args[2:])
+			var status exitStatus
+			switch {
+			case err == nil, err == flag.ErrHelp:
+				return 0
+			case errors.As(err, &status):
+				return int(status)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
 	p := tea.NewProgram(tui.NewModel(), tea.WithAltScreen())
 	finalModel, err := p.Run()
+	if m, ok := finalModel.(tui.Model); ok {
+		m.Close()
+	}
 	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Check if we need to connect to a host
 	if m, ok := finalModel.(tui.Model); ok {
 		if m.GetSelectedHost() != nil {
 			host := m.GetSelectedHost()
-			fmt.Printf("Connecting to %s@%s...\n", host.User, host.Hostname)
-			if err := tui.ExecuteSSH(*host); err != nil {
+			fmt.Printf("Connecting to %s...\n", tui.HostDestination(*host))
+			if err := tui.Connect(m.GetConfig(), *host); err != nil {
 				fmt.Printf("Error connecting to host: %v\n", err)
-				os.Exit(1)
+				return 1
 			}
 		}
 	}
+	return 0
 }
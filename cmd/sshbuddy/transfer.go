@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/internal/transfer"
+	"sshbuddy/pkg/models"
+)
+
+// loadHosts loads every enabled source and returns the merged hosts.
+// Sources that fail are reported on stderr and skipped.
+func loadHosts() ([]models.Host, error) {
+	_, hosts, err := loadConfigAndHosts()
+	return hosts, err
+}
+
+// loadConfigAndHosts is loadHosts that also returns the config file contents
+func loadConfigAndHosts() (*models.Config, []models.Host, error) {
+	cfg, err := config.LoadConfigRaw()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := config.LoadEnabledSources(cfg)
+	for _, result := range results {
+		if config.IsAuthError(result.Err) {
+			fmt.Fprintf(os.Stderr, "warning: %s needs you to log in again (run sshbuddy); skipping it\n", result.Source)
+		} else if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", result.Source, result.Err)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+
+	return cfg, config.MergeSources(cfg, results).Hosts, nil
+}
+
+// runExport implements "sshbuddy export"
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "output format: ssh, json or csv (default: from -o, else ssh)")
+	output := fs.String("o", "", "file to write (default: stdout)")
+	source := fs.String("source", "", "only hosts from this source or source kind, e.g. manual or termix")
+	filter := fs.String("filter", "", "only hosts whose alias, hostname or tags contain this text")
+	force := fs.Bool("force", false, "replace the -o file if it exists")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sshbuddy export [-format ssh|json|csv] [-o file] [-force] [-source name] [-filter text]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = transfer.FormatSSH
+		if *output != "" {
+			*format = transfer.DetectFormat(*output)
+		}
+	}
+	if !transfer.ValidFormat(*format) {
+		return fmt.Errorf("unknown format %q (valid: %s)", *format, strings.Join(transfer.Formats, ", "))
+	}
+
+	hosts, err := loadHosts()
+	if err != nil {
+		return err
+	}
+
+	var selected []models.Host
+	for _, host := range hosts {
+		if *source != "" && config.SourceRank(host.Source, []string{*source}) != 0 {
+			continue
+		}
+		if *filter != "" && !matchesFilter(host, *filter) {
+			continue
+		}
+		selected = append(selected, host)
+	}
+
+	if *output == "" {
+		skipped, err := transfer.Export(os.Stdout, selected, *format)
+		warnSkipped(skipped)
+		return err
+	}
+	skipped, err := transfer.ExportFile(*output, selected, *format, *force)
+	if err != nil {
+		if errors.Is(err, transfer.ErrFileExists) {
+			return fmt.Errorf("%w; use -force to replace it", err)
+		}
+		return err
+	}
+	warnSkipped(skipped)
+	fmt.Fprintf(os.Stderr, "Exported %d host(s) to %s\n", len(selected)-len(skipped), *output)
+	return nil
+}
+
+// warnSkipped reports hosts the export left out
+func warnSkipped(aliases []string) {
+	for _, alias := range aliases {
+		fmt.Fprintf(os.Stderr, "warning: skipped %q: a value contains a line break or a double quote\n", alias)
+	}
+}
+
+// matchesFilter reports whether text appears in the host's alias, hostname or tags
+func matchesFilter(host models.Host, text string) bool {
+	text = strings.ToLower(text)
+	fields := append([]string{host.Alias, host.Hostname}, host.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// runImport implements "sshbuddy import"
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: ssh, json or csv (default: from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "show what would be imported without saving")
+	overwrite := fs.Bool("overwrite", false, "replace existing hosts with the same alias")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sshbuddy import [-format ssh|json|csv] [-dry-run] [-overwrite] <file|->")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one file to import")
+	}
+	if *format != "" && !transfer.ValidFormat(*format) {
+		return fmt.Errorf("unknown format %q (valid: %s)", *format, strings.Join(transfer.Formats, ", "))
+	}
+
+	incoming, err := transfer.ParseFile(fs.Arg(0), *format)
+	if err != nil {
+		return err
+	}
+
+	hosts, err := loadHosts()
+	if err != nil {
+		return err
+	}
+
+	plan := transfer.PlanImport(hosts, incoming)
+	for _, entry := range plan.Entries {
+		switch entry.Status {
+		case transfer.ImportNew:
+			fmt.Printf("+ %-20s %s\n", entry.Host.Alias, entry.Host.Hostname)
+		case transfer.ImportSame:
+			fmt.Printf("= %-20s %s (already exists)\n", entry.Host.Alias, entry.Host.Hostname)
+		case transfer.ImportConflict:
+			action := "skipped"
+			if *overwrite {
+				action = "replaces it"
+			}
+			fmt.Printf("! %-20s %s (conflicts with %s host %s, %s)\n",
+				entry.Host.Alias, entry.Host.Hostname, sourceName(entry.Existing.Source), entry.Existing.Hostname, action)
+		case transfer.ImportInvalid:
+			fmt.Printf("x %-20s %s (skipped: %s)\n", entry.Host.Alias, entry.Host.Hostname, entry.Reason)
+		}
+	}
+
+	conflicts := plan.Count(transfer.ImportConflict)
+	summary := fmt.Sprintf("%d to add, %d conflict(s), %d unchanged", plan.Count(transfer.ImportNew), conflicts, plan.Count(transfer.ImportSame))
+	if invalid := plan.Count(transfer.ImportInvalid); invalid > 0 {
+		summary += fmt.Sprintf(", %d invalid", invalid)
+	}
+	if conflicts > 0 && !*overwrite {
+		summary += " (use -overwrite to replace)"
+	}
+	fmt.Println(summary)
+
+	if *dryRun {
+		fmt.Println("Dry run: nothing was saved")
+		return nil
+	}
+
+	// Apply to the file as it is now, in case it changed while we were loading
+	return config.UpdateConfig(func(cfg *models.Config) error {
+		cfg.Hosts = plan.Apply(cfg.Hosts, *overwrite)
+		return nil
+	})
+}
+
+func sourceName(source string) string {
+	if source == "" {
+		return config.SourceManual
+	}
+	return source
+}
@@ -0,0 +1,324 @@
+// Package askpass implements the SSH_ASKPASS helper used to hand stored
+// passwords to ssh without putting them on the command line or on disk.
+//
+// The parent sshbuddy process starts a Server listening on a private unix
+// socket and launches ssh with SSH_ASKPASS pointing back at the sshbuddy
+// binary. When ssh needs a password it runs that binary, which detects the
+// helper environment, asks the server over the socket and prints the answer
+// on stdout for ssh to read.
+package askpass
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+const (
+	socketEnv = "SSHBUDDY_ASKPASS_SOCKET"
+	tokenEnv  = "SSHBUDDY_ASKPASS_TOKEN"
+
+	// requestTimeout bounds a single helper exchange so a stuck client
+	// can't hold the server open.
+	requestTimeout = 5 * time.Second
+)
+
+// Secrets holds the values the server is allowed to hand out, and what
+// they're for. With a ProxyJump, ssh also authenticates to the jump hosts
+// through the helper, so secrets only go to prompts that name the
+// destination, or the key file for a passphrase.
+type Secrets struct {
+	Password      string
+	KeyPassphrase string
+
+	User      string // Destination user; empty when ssh picks it
+	Hostname  string // Destination host as given to ssh
+	KeyFile   string // Key the passphrase unlocks, if known
+	ProxyJump bool   // ssh connects through jump hosts first
+}
+
+// Server answers askpass requests for a single ssh invocation
+type Server struct {
+	listener net.Listener
+	dir      string
+	token    string
+	secrets  Secrets
+
+	mu     sync.Mutex
+	served map[string]bool // secrets already handed out (each is served once)
+	wg     sync.WaitGroup
+}
+
+// Start creates a private socket and begins serving the given secrets
+func Start(secrets Secrets) (*Server, error) {
+	dir, err := os.MkdirTemp("", "sshbuddy-askpass-")
+	if err != nil {
+		return nil, fmt.Errorf("askpass: failed to create socket dir: %w", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("askpass: failed to secure socket dir: %w", err)
+	}
+
+	listener, err := net.Listen("unix", filepath.Join(dir, "askpass.sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("askpass: failed to listen: %w", err)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		listener.Close()
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("askpass: failed to generate token: %w", err)
+	}
+
+	s := &Server{
+		listener: listener,
+		dir:      dir,
+		token:    hex.EncodeToString(tokenBytes),
+		secrets:  secrets,
+		served:   make(map[string]bool),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Env returns the environment variables ssh needs to use this server
+func (s *Server) Env() ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("askpass: failed to locate sshbuddy binary: %w", err)
+	}
+
+	env := []string{
+		"SSH_ASKPASS=" + exe,
+		"SSH_ASKPASS_REQUIRE=force",
+		socketEnv + "=" + s.listener.Addr().String(),
+		tokenEnv + "=" + s.token,
+	}
+
+	// OpenSSH releases before 8.4 ignore SSH_ASKPASS_REQUIRE and only use
+	// the helper when DISPLAY is set
+	if os.Getenv("DISPLAY") == "" {
+		env = append(env, "DISPLAY=sshbuddy:0")
+	}
+
+	return env, nil
+}
+
+// Close stops the server and removes the socket
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	os.RemoveAll(s.dir)
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+// handle reads "<token>\n<prompt>\n" and replies with the matching secret.
+// An empty reply tells the helper to fall back to the terminal.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	reader := bufio.NewReader(conn)
+	token, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.token)) != 1 {
+		return
+	}
+	prompt, _ := reader.ReadString('\n')
+
+	secret := s.secretFor(strings.TrimSpace(prompt))
+	if secret != "" {
+		conn.Write([]byte(secret))
+	}
+}
+
+// secretFor picks the secret for a prompt. Each secret is only served once
+// so a wrong stored password doesn't loop; later prompts go to the terminal.
+func (s *Server) secretFor(prompt string) string {
+	kind := promptKind(prompt)
+	if kind == "" {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.served[kind] {
+		return ""
+	}
+
+	var secret string
+	switch kind {
+	case "password":
+		if s.forDestination(prompt) {
+			secret = s.secrets.Password
+		}
+	case "passphrase":
+		if s.forKeyFile(prompt) {
+			secret = s.secrets.KeyPassphrase
+		}
+	}
+	if secret != "" {
+		s.served[kind] = true
+	}
+	return secret
+}
+
+// promptDestination matches the user@host that ssh puts in password
+// prompts: "user@host's password: " or "(user@host) Password: "
+var promptDestination = regexp.MustCompile(`([^\s()@']+)@([^\s()@']+)`)
+
+// forDestination reports whether a password prompt is for the destination
+// rather than a jump host. Prompts that don't say which host they're for,
+// such as a bare "Password:" from keyboard-interactive authentication, only
+// get an answer without jump hosts.
+func (s *Server) forDestination(prompt string) bool {
+	match := promptDestination.FindStringSubmatch(prompt)
+	if match == nil {
+		return !s.secrets.ProxyJump
+	}
+	user, host := match[1], match[2]
+	// ssh lowercases host names
+	if !strings.EqualFold(host, s.secrets.Hostname) {
+		return false
+	}
+	return s.secrets.User == "" || user == s.secrets.User
+}
+
+// forKeyFile reports whether a passphrase prompt is for the destination's
+// key: "Enter passphrase for key '/path/to/key': "
+func (s *Server) forKeyFile(prompt string) bool {
+	if s.secrets.KeyFile != "" && strings.Contains(prompt, "'"+s.secrets.KeyFile+"'") {
+		return true
+	}
+	return !s.secrets.ProxyJump
+}
+
+// promptKind classifies an ssh prompt by the secret it asks for
+func promptKind(prompt string) string {
+	lower := strings.ToLower(prompt)
+	switch {
+	case strings.Contains(lower, "passphrase"):
+		return "passphrase"
+	case strings.Contains(lower, "password"):
+		return "password"
+	default:
+		return ""
+	}
+}
+
+// IsHelper reports whether this process was started by ssh as the askpass helper
+func IsHelper() bool {
+	return os.Getenv(socketEnv) != "" && os.Getenv(tokenEnv) != ""
+}
+
+// RunHelper answers a single ssh prompt and returns the process exit code.
+// args are the arguments ssh passed to the helper (the prompt text).
+func RunHelper(args []string) int {
+	prompt := strings.Join(args, " ")
+
+	if answer, ok := askServer(prompt); ok {
+		fmt.Fprintln(os.Stdout, answer)
+		return 0
+	}
+
+	answer, err := askTerminal(prompt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sshbuddy askpass: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(os.Stdout, answer)
+	return 0
+}
+
+// askServer requests a stored secret from the parent sshbuddy process
+func askServer(prompt string) (string, bool) {
+	conn, err := net.DialTimeout("unix", os.Getenv(socketEnv), requestTimeout)
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	request := os.Getenv(tokenEnv) + "\n" + strings.ReplaceAll(prompt, "\n", " ") + "\n"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return "", false
+	}
+
+	var reply strings.Builder
+	buf := make([]byte, 1024)
+	for {
+		n, err := conn.Read(buf)
+		reply.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+
+	if reply.Len() == 0 {
+		return "", false
+	}
+	return reply.String(), true
+}
+
+// askTerminal prompts on the controlling terminal, used for host key
+// confirmations and for retries after a stored secret was rejected
+func askTerminal(prompt string) (string, error) {
+	tty, err := openTTY()
+	if err != nil {
+		return "", fmt.Errorf("no terminal available for prompt: %w", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+
+	// Confirmations (yes/no) are echoed, anything secret is not
+	if promptKind(prompt) != "" {
+		answer, err := term.ReadPassword(tty.Fd())
+		fmt.Fprintln(tty)
+		return string(answer), err
+	}
+
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(answer, "\r\n"), nil
+}
+
+func openTTY() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile("CONIN$", os.O_RDWR, 0)
+	}
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
@@ -0,0 +1,49 @@
+package askpass
+
+import "testing"
+
+func TestSecretFor(t *testing.T) {
+	direct := Secrets{Password: "target-pw", KeyPassphrase: "key-pp", User: "deploy", Hostname: "web.example.com", KeyFile: "/tmp/key"}
+	jumped := direct
+	jumped.ProxyJump = true
+	anyUser := jumped
+	anyUser.User = ""
+
+	tests := []struct {
+		name    string
+		secrets Secrets
+		prompt  string
+		want    string
+	}{
+		{"destination", direct, "deploy@web.example.com's password: ", "target-pw"},
+		{"host case", direct, "deploy@Web.Example.com's password: ", "target-pw"},
+		{"keyboard-interactive", direct, "(deploy@web.example.com) Password: ", "target-pw"},
+		{"unnamed without jump", direct, "Password: ", "target-pw"},
+		{"other host without jump", direct, "admin@other.example.com's password: ", ""},
+		{"jump host", jumped, "admin@bastion.example.com's password: ", ""},
+		{"destination after jump", jumped, "deploy@web.example.com's password: ", "target-pw"},
+		{"other user", jumped, "root@web.example.com's password: ", ""},
+		{"any user", anyUser, "root@web.example.com's password: ", "target-pw"},
+		{"unnamed with jump", jumped, "Password: ", ""},
+		{"passphrase", direct, "Enter passphrase for key '/home/me/.ssh/id_ed25519': ", "key-pp"},
+		{"own key with jump", jumped, "Enter passphrase for key '/tmp/key': ", "key-pp"},
+		{"jump key", jumped, "Enter passphrase for key '/home/me/.ssh/bastion': ", ""},
+		{"confirmation", direct, "Are you sure you want to continue connecting (yes/no)? ", ""},
+	}
+	for _, tt := range tests {
+		s := &Server{secrets: tt.secrets, served: make(map[string]bool)}
+		if got := s.secretFor(tt.prompt); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSecretForServesOnce(t *testing.T) {
+	s := &Server{secrets: Secrets{Password: "pw", Hostname: "web"}, served: make(map[string]bool)}
+	if got := s.secretFor("me@web's password: "); got != "pw" {
+		t.Fatalf("first prompt: got %q, want pw", got)
+	}
+	if got := s.secretFor("me@web's password: "); got != "" {
+		t.Errorf("retry: got %q, want the terminal to be asked", got)
+	}
+}
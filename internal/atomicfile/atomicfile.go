@@ -0,0 +1,83 @@
+// Package atomicfile writes files so that readers never see a partial write.
+// The config file, caches, the generated ssh_config and exports all use it.
+// It also remembers what it wrote, so file watchers can tell this process's
+// own writes from edits made elsewhere.
+package atomicfile
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// written holds the hash of the contents this process last wrote to each
+// path, with symlinks resolved
+var written = struct {
+	sync.Mutex
+	hashes map[string][sha256.Size]byte
+}{hashes: make(map[string][sha256.Size]byte)}
+
+// WrittenHere reports whether the file at path holds exactly what this
+// process last wrote to it, so a change to it needs no reload
+func WrittenHere(path string) bool {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	written.Lock()
+	hash, ok := written.hashes[filepath.Clean(path)]
+	written.Unlock()
+	if !ok {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && sha256.Sum256(data) == hash
+}
+
+// WriteFile replaces the file at path with data so that readers see either
+// the old or the new contents, never a partial write. The data goes to a
+// temporary file in the same directory, is synced, and is renamed over path.
+// A symlink at path is followed, so the link itself is kept.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	written.Lock()
+	written.hashes[filepath.Clean(path)] = sha256.Sum256(data)
+	written.Unlock()
+
+	// Persist the rename too. Not every platform can sync a directory, so
+	// failures are ignored; the file contents are already on disk.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sshbuddy/internal/atomicfile"
+	"sshbuddy/pkg/models"
+)
+
+// defaultBackupCount is how many config backups are kept unless the config sets backups
+const defaultBackupCount = 10
+
+// backupTimeLayout is the timestamp in backup file names; it sorts chronologically
+const backupTimeLayout = "20060102-150405.000"
+
+// Backup is a copy of the config file saved before it was replaced
+type Backup struct {
+	Path  string
+	Time  time.Time
+	Hosts int // Manual hosts in the backup
+}
+
+// BackupDir returns the directory config backups are kept in, creating it if needed
+func BackupDir() (string, error) {
+	path, err := GetDataPath()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(path), "backups")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// backupCount returns how many backups config keeps; 0 means none
+func backupCount(config *models.Config) int {
+	switch {
+	case config.Backups < 0:
+		return 0
+	case config.Backups == 0:
+		return defaultBackupCount
+	default:
+		return config.Backups
+	}
+}
+
+// backupConfig copies the config file at path to a new timestamped backup
+// and deletes all but the newest keep backups
+func backupConfig(path string, keep int) error {
+	if keep == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dir, err := BackupDir()
+	if err != nil {
+		return err
+	}
+	name := "config-" + time.Now().Format(backupTimeLayout) + filepath.Ext(path)
+	if err := atomicfile.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(backup.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListBackups returns the config backups, newest first
+func ListBackups() ([]Backup, error) {
+	dir, err := BackupDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), "config-")
+		if !ok {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeLayout, strings.TrimSuffix(stamp, filepath.Ext(stamp)), time.Local)
+		if err != nil {
+			continue
+		}
+		backup := Backup{Path: filepath.Join(dir, entry.Name()), Time: t, Hosts: -1}
+		if config, err := readConfigFile(backup.Path); err == nil {
+			backup.Hosts = len(config.Hosts)
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces the config file with the backup at path, converted
+// to the config file's current format. The current config is backed up
+// first, so a restore can be undone.
+func RestoreBackup(path string) error {
+	data, err := readConfigData(path)
+	if err != nil {
+		return err
+	}
+	var backup models.Config
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return fmt.Errorf("backup %s is not a valid config file: %w", filepath.Base(path), err)
+	}
+	// Older backups are upgraded when next loaded; newer ones can't be read
+	if _, err := needsMigration(data); err != nil {
+		return err
+	}
+
+	unlock, err := lockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	configPath, err := GetDataPath()
+	if err != nil {
+		return err
+	}
+	if current, err := readConfigFile(configPath); err == nil {
+		if err := backupConfig(configPath, backupCount(current)); err != nil {
+			return err
+		}
+	}
+	encoded, _, err := encodeConfig(configPath, data)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(configPath, encoded, 0600)
+}
+
+// readConfigFile parses the config file at path without any of LoadConfigRaw's fixups
+func readConfigFile(path string) (*models.Config, error) {
+	data, err := readConfigData(path)
+	if err != nil {
+		return nil, err
+	}
+	var config models.Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
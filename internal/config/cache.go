@@ -0,0 +1,210 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sshbuddy/internal/atomicfile"
+	"sshbuddy/pkg/models"
+)
+
+// hostCacheVersion is the format of the hosts in cache files. Caches without
+// it hold the snake_case host fields of config versions before 4 and are
+// ignored until the next successful fetch replaces them.
+const hostCacheVersion = 1
+
+// termixCache is the on-disk snapshot of the last successful Termix fetch.
+// Secrets (passwords, keys) are tagged json:"-" on models.Host and never written.
+type termixCache struct {
+	Version   int           `json:"version"`
+	FetchedAt time.Time     `json:"fetchedAt"`
+	BaseURL   string        `json:"baseUrl"`
+	Hosts     []models.Host `json:"hosts"`
+}
+
+// GetCacheDir returns the sshbuddy cache directory, creating it if needed
+func GetCacheDir() (string, error) {
+	// Use XDG_CACHE_HOME if set, otherwise default to ~/.cache
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+
+	sshbuddyDir := filepath.Join(cacheDir, "sshbuddy")
+	if err := os.MkdirAll(sshbuddyDir, 0700); err != nil {
+		return "", err
+	}
+
+	return sshbuddyDir, nil
+}
+
+// termixCachePath returns the cache file for the Termix server called name
+func termixCachePath(name string) (string, error) {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "termix-hosts-"+name+".json"), nil
+}
+
+// saveTermixCache stores the hosts from a successful Termix fetch
+func saveTermixCache(name, baseURL string, hosts []models.Host) error {
+	path, err := termixCachePath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(termixCache{
+		Version:   hostCacheVersion,
+		FetchedAt: time.Now(),
+		BaseURL:   baseURL,
+		Hosts:     hosts,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// renameTermixCache moves the cache of the Termix server oldName to newName
+func renameTermixCache(oldName, newName string) error {
+	oldPath, err := termixCachePath(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := termixCachePath(newName)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeTermixCache deletes the cache of the Termix server called name
+func removeTermixCache(name string) error {
+	path, err := termixCachePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadTermixCache returns the cached hosts of server name for baseURL, if any
+func loadTermixCache(name, baseURL string) (*termixCache, error) {
+	path, err := termixCachePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache termixCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	if cache.Version != hostCacheVersion {
+		return nil, fmt.Errorf("termix cache has an older format")
+	}
+	if cache.BaseURL != baseURL {
+		return nil, fmt.Errorf("termix cache is for %s, not %s", cache.BaseURL, baseURL)
+	}
+
+	for i := range cache.Hosts {
+		cache.Hosts[i].Stale = true
+	}
+
+	return &cache, nil
+}
+
+// commandCache is the on-disk snapshot of the last successful command run
+type commandCache struct {
+	Version   int           `json:"version"`
+	FetchedAt time.Time     `json:"fetchedAt"`
+	Command   string        `json:"command"`
+	Hosts     []models.Host `json:"hosts"`
+}
+
+// commandCachePath returns the cache file for the command source called name
+func commandCachePath(name string) (string, error) {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "command-hosts-"+name+".json"), nil
+}
+
+// saveCommandCache stores the hosts from a successful command run
+func saveCommandCache(name, command string, hosts []models.Host) error {
+	path, err := commandCachePath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(commandCache{
+		Version:   hostCacheVersion,
+		FetchedAt: time.Now(),
+		Command:   command,
+		Hosts:     hosts,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// loadCommandCache returns the cached hosts of source name, if they were
+// produced by the same command
+func loadCommandCache(name, command string) (*commandCache, error) {
+	path, err := commandCachePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache commandCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	if cache.Version != hostCacheVersion {
+		return nil, fmt.Errorf("command cache has an older format")
+	}
+	if cache.Command != command {
+		return nil, fmt.Errorf("command cache is for a different command")
+	}
+	return &cache, nil
+}
+
+// formatAge renders a cache age like "5m ago", "3h ago" or "2d ago"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// configFileNames are the config file names looked for, in order. The
+// hand-written formats come first, so a new config.yaml or config.toml
+// takes over from the config.json SSHBuddy created.
+var configFileNames = []string{"config.yaml", "config.yml", "config.toml", "config.json"}
+
+// ConfigFormat returns the format of the config file at path, from its extension
+func ConfigFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// toJSON converts config file contents in format to JSON, so that the rest
+// of the package (migrations, models.Config) only deals with one format
+func toJSON(data []byte, format string) ([]byte, error) {
+	var raw any
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			// An empty file, or one with only comments
+			raw = map[string]any{}
+		}
+	case FormatTOML:
+		var table map[string]any
+		if err := toml.Unmarshal(data, &table); err != nil {
+			return nil, err
+		}
+		raw = table
+	default:
+		return data, nil
+	}
+	return json.Marshal(portsAsStrings(raw))
+}
+
+// portsAsStrings turns numeric "port" values into strings. Ports are strings
+// in the config, but people writing YAML or TOML by hand use numbers.
+func portsAsStrings(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			switch n := item.(type) {
+			case int:
+				if key == "port" {
+					item = strconv.Itoa(n)
+				}
+			case int64:
+				if key == "port" {
+					item = strconv.FormatInt(n, 10)
+				}
+			}
+			v[key] = portsAsStrings(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = portsAsStrings(item)
+		}
+	case []map[string]any:
+		// TOML arrays of tables
+		for _, item := range v {
+			portsAsStrings(item)
+		}
+	}
+	return value
+}
+
+// fromJSON converts JSON config contents to format. For YAML, comments in
+// previous, the file being replaced, are carried over to the matching keys.
+// TOML is written without comments.
+func fromJSON(data []byte, format string, previous []byte) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		// YAML is a superset of JSON, so this keeps the key order
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		blockStyle(&doc)
+
+		var old yaml.Node
+		if len(previous) > 0 && yaml.Unmarshal(previous, &old) == nil {
+			carryComments(&old, &doc)
+		}
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatTOML:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var raw map[string]any
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = "  "
+		if err := enc.Encode(tomlValue(raw)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return data, nil
+	}
+}
+
+// blockStyle clears the flow and quoting styles YAML keeps from JSON input,
+// so the encoder writes plain block YAML and quotes only where needed
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// carryComments copies the comments of old onto the nodes of node that sit
+// at the same place: the same key in a mapping, or in a list, the entry with
+// the same alias or name (or position, for entries without either)
+func carryComments(old, node *yaml.Node) {
+	node.HeadComment = old.HeadComment
+	node.LineComment = old.LineComment
+	node.FootComment = old.FootComment
+
+	switch {
+	case node.Kind == yaml.DocumentNode && old.Kind == yaml.DocumentNode:
+		if len(node.Content) > 0 && len(old.Content) > 0 {
+			carryComments(old.Content[0], node.Content[0])
+		}
+	case node.Kind == yaml.MappingNode && old.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			for j := 0; j+1 < len(old.Content); j += 2 {
+				if old.Content[j].Value == node.Content[i].Value {
+					carryComments(old.Content[j], node.Content[i])
+					carryComments(old.Content[j+1], node.Content[i+1])
+					break
+				}
+			}
+		}
+	case node.Kind == yaml.SequenceNode && old.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			if match := matchingItem(old, item, i); match != nil {
+				carryComments(match, item)
+			}
+		}
+	}
+}
+
+// matchingItem returns the entry of the old list that corresponds to item,
+// the entry at index in the new list
+func matchingItem(old, item *yaml.Node, index int) *yaml.Node {
+	for _, key := range []string{"alias", "name"} {
+		id := mappingValue(item, key)
+		if id == "" {
+			continue
+		}
+		for _, candidate := range old.Content {
+			if mappingValue(candidate, key) == id {
+				return candidate
+			}
+		}
+		return nil
+	}
+	if index < len(old.Content) {
+		return old.Content[index]
+	}
+	return nil
+}
+
+// mappingValue returns the scalar value of key in a mapping node, or ""
+func mappingValue(node *yaml.Node, key string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.ScalarNode {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// tomlValue prepares decoded JSON for the TOML encoder: TOML has no null, so
+// nulls are dropped, and numbers become integers where they are whole
+func tomlValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		table := make(map[string]any, len(v))
+		for key, item := range v {
+			if item != nil {
+				table[key] = tomlValue(item)
+			}
+		}
+		return table
+	case []any:
+		var list []any
+		for _, item := range v {
+			if item != nil {
+				list = append(list, tomlValue(item))
+			}
+		}
+		return list
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// FileError is a config file that couldn't be parsed. Line and Column locate
+// the problem when the parser reports where it is.
+type FileError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *FileError) Error() string {
+	where := filepath.Base(e.Path)
+	if e.Line > 0 && e.Column > 0 {
+		where += fmt.Sprintf(", line %d, column %d", e.Line, e.Column)
+	}
+	return where + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// configFileError wraps a parse error of the config file at path, whose
+// contents are data, in a FileError
+func configFileError(path string, data []byte, err error) error {
+	fileErr := &FileError{Path: path, Err: err}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tomlErr toml.ParseError
+	switch {
+	case errors.As(err, &syntaxErr):
+		fileErr.Line, fileErr.Column = offsetPosition(data, syntaxErr.Offset-1)
+	case errors.As(err, &typeErr):
+		fileErr.Line, fileErr.Column = offsetPosition(data, typeErr.Offset-1)
+	case errors.As(err, &tomlErr):
+		// The position goes in the fields rather than repeated in the message
+		fileErr.Line, fileErr.Column = tomlErr.Position.Line, tomlErr.Position.Col
+		fileErr.Err = errors.New(tomlErr.Message)
+	}
+	return fileErr
+}
+
+// offsetPosition returns the 1-based line and column of the byte at offset
+func offsetPosition(data []byte, offset int64) (line, column int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, column
+}
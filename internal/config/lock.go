@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockConfig takes the advisory lock that serializes config writes between
+// sshbuddy processes, waiting if another one holds it. Call the returned
+// function to release it.
+func lockConfig() (func(), error) {
+	path, err := GetDataPath()
+	if err != nil {
+		return nil, err
+	}
+	// One lock for every config format, so switching formats can't bypass it
+	f, err := os.OpenFile(filepath.Join(filepath.Dir(path), "config.lock"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
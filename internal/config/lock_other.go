@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package config
+
+import "os"
+
+// lockFile is a no-op where advisory locks aren't available; writes are
+// still atomic, but concurrent updates may overwrite each other
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sshbuddy/internal/atomicfile"
+	"sshbuddy/internal/transfer"
+	"sshbuddy/pkg/models"
+)
+
+// Markers fencing the text sshbuddy owns in the generated file and in ~/.ssh/config
+const (
+	managedBegin = "# >>> managed by sshbuddy >>>"
+	managedEnd   = "# <<< managed by sshbuddy <<<"
+)
+
+// ManagedSSHConfigPath returns the path of the generated ssh_config, next to the config file
+func ManagedSSHConfigPath() (string, error) {
+	path, err := GetDataPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "ssh_config"), nil
+}
+
+// userSSHConfigPath returns the ssh_config that ssh reads by default
+func userSSHConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".ssh", "config"), nil
+}
+
+// UpdateManagedSSHConfig writes the generated ssh_config and adds or removes
+// its Include in ~/.ssh/config to match config.ManagedConfig. When the
+// feature is off, anything it wrote before is removed.
+func UpdateManagedSSHConfig(config *models.Config) error {
+	path, err := ManagedSSHConfigPath()
+	if err != nil {
+		return err
+	}
+	userConfig, err := userSSHConfigPath()
+	if err != nil {
+		return err
+	}
+
+	if !config.ManagedConfig.Enabled {
+		if err := removeManagedBlock(userConfig); err != nil {
+			return err
+		}
+		return removeManagedFile(path)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(managedBegin + "\n")
+	b.WriteString("# Generated from your sshbuddy hosts. Changes here are overwritten;\n")
+	b.WriteString("# edit the hosts in sshbuddy instead.\n")
+	skipped, err := transfer.WriteSSHHosts(&b, managedHosts(config))
+	if err != nil {
+		return err
+	}
+	for _, alias := range skipped {
+		fmt.Fprintf(&b, "\n# Skipped %q: a value contains a line break or a double quote\n", alias)
+		slog.Warn("Left host out of managed ssh_config", "alias", alias, "reason", "line break or double quote in a value")
+	}
+	b.WriteString("\n" + managedEnd + "\n")
+	if err := writeIfChanged(path, b.Bytes(), 0600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if !config.ManagedConfig.Include {
+		return removeManagedBlock(userConfig)
+	}
+	return addManagedBlock(userConfig, "Include "+includePath(path))
+}
+
+// refreshManagedSSHConfig regenerates the ssh_config after a source changed
+// outside SaveConfig, such as a Termix fetch. Failures are only logged.
+func refreshManagedSSHConfig() {
+	config, err := LoadConfigRaw()
+	if err != nil || !config.ManagedConfig.Enabled {
+		return
+	}
+	if err := UpdateManagedSSHConfig(config); err != nil {
+		slog.Warn("Updating managed ssh_config failed", "err", err)
+	}
+}
+
+// SkippedManagedHosts returns the aliases of hosts left out of the generated
+// ssh_config because a value can't be written safely
+func SkippedManagedHosts(config *models.Config) []string {
+	skipped, _ := transfer.WriteSSHHosts(io.Discard, managedHosts(config))
+	return skipped
+}
+
+// managedHosts returns the manual and Termix hosts to write, merged the same
+// way as in the host list. Termix hosts come from the offline cache so that
+// saving never waits on the network.
+func managedHosts(config *models.Config) []models.Host {
+	var results []SourceResult
+	for _, source := range EnabledSources(config) {
+		kind, instance := SplitSourceName(source)
+		switch kind {
+		case SourceManual:
+			var hosts []models.Host
+			for _, host := range config.Hosts {
+				if host.Source == "" || host.Source == SourceManual {
+					hosts = append(hosts, host)
+				}
+			}
+			results = append(results, SourceResult{Source: source, Hosts: hosts})
+		case SourceTermix:
+			server := FindTermixServer(config, instance)
+			if server == nil {
+				continue
+			}
+			cache, err := loadTermixCache(server.Name, server.BaseURL)
+			if err != nil {
+				continue
+			}
+			results = append(results, SourceResult{Source: source, Hosts: applyTermixServer(cache.Hosts, *server)})
+		}
+	}
+
+	var hosts []models.Host
+	for _, host := range MergeSources(config, results).Hosts {
+		// ssh would read these as patterns rather than a host name
+		if strings.ContainsAny(host.Alias, "*?!") {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// includePath returns path as an Include argument, using ~ inside the home directory
+func includePath(path string) string {
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(homeDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = "~/" + filepath.ToSlash(rel)
+		}
+	}
+	if strings.ContainsAny(path, " \t") {
+		return `"` + path + `"`
+	}
+	return path
+}
+
+// addManagedBlock puts line between the markers at the top of the file at
+// path, replacing an existing block. Include has to come before the first
+// Host line, or ssh only applies it to that host.
+func addManagedBlock(path, line string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	block := managedBegin + "\n" + line + "\n" + managedEnd + "\n"
+	rest, _ := cutManagedBlock(string(data))
+	updated := block
+	if rest != "" {
+		updated += "\n" + rest
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeIfChanged(path, []byte(updated), 0600)
+}
+
+// removeManagedBlock removes the marked block from the file at path, if any
+func removeManagedBlock(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rest, found := cutManagedBlock(string(data))
+	if !found {
+		return nil
+	}
+	return writeIfChanged(path, []byte(rest), 0600)
+}
+
+// cutManagedBlock returns content without the marked block and the blank
+// line after it, and whether there was a block
+func cutManagedBlock(content string) (string, bool) {
+	start := strings.Index(content, managedBegin)
+	if start < 0 {
+		return content, false
+	}
+	end := strings.Index(content[start:], managedEnd)
+	if end < 0 {
+		return content, false
+	}
+	end += start + len(managedEnd)
+
+	after := strings.TrimPrefix(content[end:], "\n")
+	after = strings.TrimPrefix(after, "\n")
+	return content[:start] + after, true
+}
+
+// removeManagedFile deletes the generated file, but only if sshbuddy wrote it
+func removeManagedFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, []byte(managedBegin)) {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// writeIfChanged writes data to path unless the file already holds it.
+// New files get perm; existing files keep their mode.
+func writeIfChanged(path string, data []byte, perm os.FileMode) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return atomicfile.WriteFile(path, data, perm)
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"sshbuddy/pkg/models"
+)
+
+// Shadowed is an alias defined by more than one source
+type Shadowed struct {
+	Alias  string
+	Winner string   // Source whose host is shown
+	Hidden []string // Sources whose hosts are hidden, highest precedence first
+	Merged bool     // Fields of the hidden hosts were merged into the shown one
+}
+
+// Duplicate is an address reachable under several aliases
+type Duplicate struct {
+	Address string        // hostname:port
+	Hosts   []models.Host // The shown hosts with that address
+}
+
+// MergeResult is the combined host list and the conflicts found building it
+type MergeResult struct {
+	Hosts      []models.Host
+	Shadowed   []Shadowed
+	Duplicates []Duplicate
+}
+
+// Conflicts returns the number of shadowed aliases and duplicate addresses
+func (r MergeResult) Conflicts() int {
+	return len(r.Shadowed) + len(r.Duplicates)
+}
+
+// SourceRank returns the position of source in precedence, matching either
+// the full source name or its kind. Unlisted sources rank after all listed ones.
+func SourceRank(source string, precedence []string) int {
+	kind, _ := SplitSourceName(source)
+	for i, entry := range precedence {
+		if entry == source || entry == kind {
+			return i
+		}
+	}
+	return len(precedence)
+}
+
+// Merge combines source results. Results are ordered by opts.Precedence,
+// falling back to the given order. When several sources define the same
+// alias the highest one wins; with opts.Fields its empty fields are filled
+// from the others and all tags are kept.
+func Merge(results []SourceResult, opts models.MergeConfig) MergeResult {
+	ordered := append([]SourceResult(nil), results...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return SourceRank(ordered[i].Source, opts.Precedence) < SourceRank(ordered[j].Source, opts.Precedence)
+	})
+
+	merged := MergeResult{Hosts: []models.Host{}}
+	byAlias := make(map[string]int)  // Alias -> index in merged.Hosts
+	shadowed := make(map[string]int) // Alias -> index in merged.Shadowed
+	var sourceOf []string            // Source of each host in merged.Hosts
+	for _, result := range ordered {
+		for _, host := range result.Hosts {
+			idx, exists := byAlias[host.Alias]
+			if !exists {
+				byAlias[host.Alias] = len(merged.Hosts)
+				merged.Hosts = append(merged.Hosts, host)
+				sourceOf = append(sourceOf, result.Source)
+				continue
+			}
+
+			winner := &merged.Hosts[idx]
+			if opts.Fields {
+				mergeFields(winner, host)
+			}
+			s, ok := shadowed[host.Alias]
+			if !ok {
+				s = len(merged.Shadowed)
+				shadowed[host.Alias] = s
+				merged.Shadowed = append(merged.Shadowed, Shadowed{
+					Alias:  host.Alias,
+					Winner: sourceOf[idx],
+					Merged: opts.Fields,
+				})
+			}
+			merged.Shadowed[s].Hidden = append(merged.Shadowed[s].Hidden, result.Source)
+		}
+	}
+
+	merged.Duplicates = findDuplicates(merged.Hosts)
+	return merged
+}
+
+// mergeFields fills the empty fields of host from other and adds the tags
+// host doesn't have yet. Hosts are matched by alias alone, which may not be
+// the same machine, so the password, key and key passphrase are only taken
+// when both hosts have the same hostname.
+func mergeFields(host *models.Host, other models.Host) {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	sameMachine := host.Hostname != "" && strings.EqualFold(host.Hostname, other.Hostname)
+
+	fill(&host.Hostname, other.Hostname)
+	fill(&host.User, other.User)
+	fill(&host.Port, other.Port)
+	fill(&host.IdentityFile, other.IdentityFile)
+	fill(&host.ProxyJump, other.ProxyJump)
+	if sameMachine {
+		fill(&host.Password, other.Password)
+		// A passphrase belongs to its key
+		if host.Key == "" {
+			host.Key, host.KeyPassphrase = other.Key, other.KeyPassphrase
+		}
+	}
+	if len(host.LocalForwards) == 0 {
+		host.LocalForwards = other.LocalForwards
+	}
+
+	// Copy before appending so the source's own tag slice is left alone
+	tags := append([]string(nil), host.Tags...)
+	for _, tag := range other.Tags {
+		if !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	host.Tags = tags
+}
+
+// findDuplicates groups hosts that point at the same hostname and port
+func findDuplicates(hosts []models.Host) []Duplicate {
+	var duplicates []Duplicate
+	byAddress := make(map[string]int) // Address -> index in duplicates, or -1 when seen once
+	first := make(map[string]models.Host)
+	for _, host := range hosts {
+		if host.Hostname == "" {
+			continue
+		}
+		address := HostAddress(host)
+		idx, seen := byAddress[address]
+		switch {
+		case !seen:
+			byAddress[address] = -1
+			first[address] = host
+		case idx == -1:
+			byAddress[address] = len(duplicates)
+			duplicates = append(duplicates, Duplicate{Address: address, Hosts: []models.Host{first[address], host}})
+		default:
+			duplicates[idx].Hosts = append(duplicates[idx].Hosts, host)
+		}
+	}
+	return duplicates
+}
+
+// HostAddress returns the lowercased hostname:port of host, with port 22 by default
+func HostAddress(host models.Host) string {
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	return strings.ToLower(host.Hostname) + ":" + port
+}
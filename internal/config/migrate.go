@@ -0,0 +1,249 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"sshbuddy/internal/atomicfile"
+	"sshbuddy/pkg/models"
+)
+
+// CurrentConfigVersion is the config file schema version this build reads and writes
+const CurrentConfigVersion = 4
+
+// migration upgrades a config file from version from to from+1. It works on
+// the decoded JSON rather than models.Config, so it can still read fields
+// that the current schema has dropped or renamed.
+type migration struct {
+	from        int
+	description string
+	apply       func(raw map[string]any) error
+}
+
+// migrations are run in order on files older than CurrentConfigVersion.
+// To change the schema, bump CurrentConfigVersion and add a step here.
+var migrations = []migration{
+	{
+		from:        1,
+		description: "move the single termix server into termixServers",
+		apply:       migrateTermixServers,
+	},
+	{
+		from:        2,
+		description: "fold sources.termixEnabled into each termix server",
+		apply:       migrateTermixEnabled,
+	},
+	{
+		from:        3,
+		description: "rename snake_case host and overlay keys to camelCase",
+		apply:       migrateCamelCaseKeys,
+	},
+}
+
+// configVersion returns the schema version of the decoded config file.
+// Files written before versioning have none and count as version 1.
+func configVersion(raw map[string]any) (int, error) {
+	value, ok := raw["version"]
+	if !ok {
+		return 1, nil
+	}
+	version, ok := value.(float64)
+	if !ok || version < 1 || version != float64(int(version)) {
+		return 0, fmt.Errorf("invalid config version %v", value)
+	}
+	return int(version), nil
+}
+
+// needsMigration reports whether the config file contents in data are older
+// than CurrentConfigVersion. It fails for files newer than this build knows.
+func needsMigration(data []byte) (bool, error) {
+	version, err := fileVersion(data)
+	if err != nil {
+		return false, err
+	}
+	return version < CurrentConfigVersion, nil
+}
+
+// fileVersion returns the version of the config file contents in data. It
+// fails for files newer than this build knows.
+func fileVersion(data []byte) (int, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return 0, err
+	}
+	version, err := configVersion(raw)
+	if err != nil {
+		return 0, err
+	}
+	if version > CurrentConfigVersion {
+		return 0, fmt.Errorf("config file is version %d, but this sshbuddy only understands up to version %d; please upgrade sshbuddy", version, CurrentConfigVersion)
+	}
+	return version, nil
+}
+
+// migrateConfig upgrades the config file contents in data to
+// CurrentConfigVersion one step at a time
+func migrateConfig(data []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	version, err := configVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range migrations {
+		if step.from < version {
+			continue
+		}
+		if step.from != version {
+			return nil, fmt.Errorf("no migration from config version %d", version)
+		}
+		if err := step.apply(raw); err != nil {
+			return nil, fmt.Errorf("migrating config from version %d (%s): %w", step.from, step.description, err)
+		}
+		version++
+		raw["version"] = version
+		slog.Info("Migrated config", "version", version, "step", step.description)
+	}
+	if version != CurrentConfigVersion {
+		return nil, fmt.Errorf("no migration from config version %d", version)
+	}
+
+	return json.MarshalIndent(raw, "", "  ")
+}
+
+// upgradeConfigFile migrates the config file at path, whose contents are
+// data, to CurrentConfigVersion and returns the new contents. The file is
+// backed up before it is rewritten. locked says whether the caller already
+// holds the config lock.
+func upgradeConfigFile(path string, data []byte, locked bool) ([]byte, error) {
+	needed, err := needsMigration(data)
+	if err != nil || !needed {
+		return data, err
+	}
+
+	if !locked {
+		unlock, err := lockConfig()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		// Another sshbuddy may have upgraded the file while we waited
+		if data, err = readConfigData(path); err != nil {
+			return nil, err
+		}
+		if needed, err := needsMigration(data); err != nil || !needed {
+			return data, err
+		}
+	}
+
+	migrated, err := migrateConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var config models.Config
+	if err := json.Unmarshal(migrated, &config); err != nil {
+		return nil, err
+	}
+	encoded, _, err := encodeConfig(path, migrated)
+	if err != nil {
+		return nil, err
+	}
+	if err := backupConfig(path, backupCount(&config)); err != nil {
+		return nil, fmt.Errorf("backing up config before upgrading it: %w", err)
+	}
+	if err := atomicfile.WriteFile(path, encoded, 0600); err != nil {
+		return nil, err
+	}
+	return migrated, nil
+}
+
+// migrateTermixServers moves the "termix" object of version 1 files, which
+// supported one server, to the front of "termixServers". A server that was
+// never set up is dropped.
+func migrateTermixServers(raw map[string]any) error {
+	value, ok := raw["termix"]
+	if !ok {
+		return nil
+	}
+	delete(raw, "termix")
+
+	legacy, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	enabled, _ := legacy["enabled"].(bool)
+	baseURL, _ := legacy["baseUrl"].(string)
+	if !enabled && baseURL == "" {
+		return nil
+	}
+	if name, _ := legacy["name"].(string); name == "" {
+		legacy["name"] = defaultTermixServerName
+	}
+
+	servers, _ := raw["termixServers"].([]any)
+	raw["termixServers"] = append([]any{legacy}, servers...)
+	return nil
+}
+
+// migrateTermixEnabled drops the global Termix switch of version 2 files.
+// When it was off, which was also the default, every server is disabled, so
+// the same hosts are shown as before.
+func migrateTermixEnabled(raw map[string]any) error {
+	sources, ok := raw["sources"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	enabled, _ := sources["termixEnabled"].(bool)
+	delete(sources, "termixEnabled")
+	if enabled {
+		return nil
+	}
+
+	servers, _ := raw["termixServers"].([]any)
+	for _, value := range servers {
+		if server, ok := value.(map[string]any); ok {
+			server["enabled"] = false
+		}
+	}
+	return nil
+}
+
+// camelCaseKeys maps the snake_case keys of hosts and overlays in version 3
+// files to the camelCase used everywhere else in the file
+var camelCaseKeys = map[string]string{
+	"identity_file":  "identityFile",
+	"proxy_jump":     "proxyJump",
+	"local_forwards": "localForwards",
+	"source_id":      "sourceId",
+}
+
+// migrateCamelCaseKeys renames the snake_case keys of hosts and overlays. A
+// camelCase key that is already there wins over its snake_case twin.
+func migrateCamelCaseKeys(raw map[string]any) error {
+	for _, list := range []string{"hosts", "overlays"} {
+		entries, _ := raw[list].([]any)
+		for _, value := range entries {
+			entry, ok := value.(map[string]any)
+			if !ok {
+				continue
+			}
+			for snake, camel := range camelCaseKeys {
+				old, ok := entry[snake]
+				if !ok {
+					continue
+				}
+				delete(entry, snake)
+				if _, exists := entry[camel]; !exists {
+					entry[camel] = old
+				}
+			}
+		}
+	}
+	return nil
+}
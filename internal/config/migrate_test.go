@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sshbuddy/pkg/models"
+)
+
+// useTempConfigDir points the config and cache directories at a new
+// temporary directory and returns the path of config.json in it
+func useTempConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	path, err := GetDataPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// decodeJSON decodes data into generic values, so documents can be compared
+// regardless of key order and formatting
+func decodeJSON(t *testing.T, name string, data []byte) any {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return value
+}
+
+// TestMigrateFixtures upgrades testdata/config-vN.json, a file as version N
+// wrote it, and compares the result with testdata/config-vN.want.json.
+// Version 0 is a file from before versioning, without a version key.
+func TestMigrateFixtures(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/config-v*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tested := 0
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".want.json") {
+			continue
+		}
+		tested++
+		want := strings.TrimSuffix(input, ".json") + ".want.json"
+
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantData, err := os.ReadFile(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			migrated, err := migrateConfig(data)
+			if err != nil {
+				t.Fatalf("migrateConfig: %v", err)
+			}
+			got, expected := decodeJSON(t, "migrated", migrated), decodeJSON(t, want, wantData)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("migrated config differs from %s:\n%s", want, migrated)
+			}
+
+			// Every key left must be one the current schema reads
+			decoder := json.NewDecoder(bytes.NewReader(migrated))
+			decoder.DisallowUnknownFields()
+			var config models.Config
+			if err := decoder.Decode(&config); err != nil {
+				t.Errorf("migrated config doesn't fit models.Config: %v", err)
+			}
+		})
+	}
+
+	// One fixture per version before the current one, plus version 0
+	if tested != CurrentConfigVersion {
+		t.Errorf("found %d fixtures, want one for each of versions 0 to %d", tested, CurrentConfigVersion-1)
+	}
+}
+
+// TestMigrateCurrentVersion checks that current files are left alone
+func TestMigrateCurrentVersion(t *testing.T) {
+	data, err := os.ReadFile(fmt.Sprintf("testdata/config-v%d.want.json", CurrentConfigVersion-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	needed, err := needsMigration(data)
+	if err != nil || needed {
+		t.Errorf("needsMigration = %v, %v; want false, nil", needed, err)
+	}
+}
+
+// TestLoadUpgradesFile checks that loading an old file rewrites it at the
+// current version and keeps the original as a backup
+func TestLoadUpgradesFile(t *testing.T) {
+	path := useTempConfigDir(t)
+	original, err := os.ReadFile("testdata/config-v2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfigRaw()
+	if err != nil {
+		t.Fatalf("LoadConfigRaw: %v", err)
+	}
+	if config.Version != CurrentConfigVersion {
+		t.Errorf("loaded version %d, want %d", config.Version, CurrentConfigVersion)
+	}
+	if len(config.Overlays) != 1 || config.Overlays[0].IdentityFile != "~/.ssh/prod_key" {
+		t.Errorf("overlay identity file lost in migration: %+v", config.Overlays)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if needed, err := needsMigration(data); err != nil || needed {
+		t.Errorf("file wasn't upgraded on disk: needsMigration = %v, %v", needed, err)
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("got %d backups, want 1", len(backups))
+	}
+	backup, err := os.ReadFile(backups[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(backup, original) {
+		t.Errorf("backup doesn't hold the original file:\n%s", backup)
+	}
+}
+
+// TestLoadRefusesNewerVersion checks that a file from a newer sshbuddy is
+// neither loaded nor rewritten, so settings this build doesn't know survive
+func TestLoadRefusesNewerVersion(t *testing.T) {
+	path := useTempConfigDir(t)
+	original, err := os.ReadFile("testdata/config-future.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadConfigRaw(); err == nil || !strings.Contains(err.Error(), "upgrade sshbuddy") {
+		t.Errorf("LoadConfigRaw: got %v, want an error asking to upgrade", err)
+	}
+	if _, err := migrateConfig(original); err == nil {
+		t.Error("migrateConfig accepted a newer version")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, original) {
+		t.Errorf("newer config file was rewritten:\n%s", data)
+	}
+	if backups, err := ListBackups(); err != nil || len(backups) != 0 {
+		t.Errorf("got backups %v, %v; want none", backups, err)
+	}
+}
+
+// TestReadConfigLeavesFile checks that ReadConfig upgrades an old file in
+// memory only and reports the version on disk
+func TestReadConfigLeavesFile(t *testing.T) {
+	path := useTempConfigDir(t)
+	original, err := os.ReadFile("testdata/config-v2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, version, err := ReadConfig()
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if version != 2 || config.Version != CurrentConfigVersion {
+		t.Errorf("got file version %d, loaded version %d; want 2, %d", version, config.Version, CurrentConfigVersion)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, original) {
+		t.Errorf("ReadConfig rewrote the config file:\n%s", data)
+	}
+	if backups, err := ListBackups(); err != nil || len(backups) != 0 {
+		t.Errorf("got backups %v, %v; want none", backups, err)
+	}
+}
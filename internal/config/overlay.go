@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+
+	"sshbuddy/pkg/models"
+)
+
+// HostID returns the stable ID of host within its source: its SourceID if
+// the source sets one, otherwise its alias
+func HostID(host models.Host) string {
+	if host.SourceID != "" {
+		return host.SourceID
+	}
+	return host.Alias
+}
+
+// FindOverlay returns the overlay for the host with id in source, or nil
+func FindOverlay(config *models.Config, source, id string) *models.HostOverlay {
+	for i := range config.Overlays {
+		if config.Overlays[i].Source == source && config.Overlays[i].ID == id {
+			return &config.Overlays[i]
+		}
+	}
+	return nil
+}
+
+// ApplyOverlay returns host with the overlay's fields layered on top
+func ApplyOverlay(host models.Host, overlay models.HostOverlay) models.Host {
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	set(&host.User, overlay.User)
+	set(&host.Port, overlay.Port)
+	set(&host.IdentityFile, overlay.IdentityFile)
+	set(&host.ProxyJump, overlay.ProxyJump)
+	set(&host.Note, overlay.Note)
+
+	// Copy before appending so the source's own tag slice is left alone
+	tags := append([]string(nil), host.Tags...)
+	for _, tag := range overlay.Tags {
+		if !containsTag(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	host.Tags = tags
+	host.Overlaid = true
+	return host
+}
+
+// ApplyOverlays returns copies of results with the config's overlays
+// applied. The hosts in results are not modified.
+func ApplyOverlays(results []SourceResult, overlays []models.HostOverlay) []SourceResult {
+	if len(overlays) == 0 {
+		return results
+	}
+
+	applied := make([]SourceResult, len(results))
+	for i, result := range results {
+		hosts := make([]models.Host, len(result.Hosts))
+		for j, host := range result.Hosts {
+			hosts[j] = host
+			for _, overlay := range overlays {
+				if overlay.Source == result.Source && overlay.ID == HostID(host) {
+					hosts[j] = ApplyOverlay(host, overlay)
+					break
+				}
+			}
+		}
+		result.Hosts = hosts
+		applied[i] = result
+	}
+	return applied
+}
+
+// SetOverlay stores the difference between base, a host as its read-only
+// source provides it, and edited as the overlay for base. An overlay that
+// changes nothing is removed. Callers save the config.
+func SetOverlay(config *models.Config, base, edited models.Host) error {
+	if edited.Alias != base.Alias || edited.Hostname != base.Hostname {
+		return fmt.Errorf("the alias and hostname of %s hosts can't be changed", sourceOrManual(base.Source))
+	}
+
+	overlay := models.HostOverlay{Source: base.Source, ID: HostID(base)}
+	diff := func(field *string, baseValue, editedValue string) {
+		if editedValue != baseValue {
+			*field = editedValue
+		}
+	}
+	diff(&overlay.User, base.User, edited.User)
+	diff(&overlay.Port, base.Port, edited.Port)
+	diff(&overlay.IdentityFile, base.IdentityFile, edited.IdentityFile)
+	diff(&overlay.ProxyJump, base.ProxyJump, edited.ProxyJump)
+	diff(&overlay.Note, base.Note, edited.Note)
+	for _, tag := range edited.Tags {
+		if !containsTag(base.Tags, tag) {
+			overlay.Tags = append(overlay.Tags, tag)
+		}
+	}
+
+	RemoveOverlay(config, overlay.Source, overlay.ID)
+	if !overlay.IsEmpty() {
+		config.Overlays = append(config.Overlays, overlay)
+	}
+	return nil
+}
+
+// RemoveOverlay deletes the overlay for the host with id in source, if any
+func RemoveOverlay(config *models.Config, source, id string) {
+	for i, overlay := range config.Overlays {
+		if overlay.Source == source && overlay.ID == id {
+			config.Overlays = append(config.Overlays[:i], config.Overlays[i+1:]...)
+			return
+		}
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func sourceOrManual(source string) string {
+	if source == "" {
+		return SourceManual
+	}
+	return source
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sshbuddy/pkg/models"
+)
+
+// Secret store backends, as set in models.Config.SecretStore
+const (
+	SecretStoreFile    = "file"
+	SecretStoreKeyring = "keyring"
+)
+
+// secretTermixJWTLegacy is where the token of the single Termix server of
+// older versions was kept; it now belongs to the default server
+const secretTermixJWTLegacy = "termix.jwt"
+
+// termixJWTKey returns the secret store key for the token of server name
+func termixJWTKey(name string) string {
+	return "termix." + name + ".jwt"
+}
+
+// SecretStore keeps tokens and passwords out of the config file
+type SecretStore interface {
+	// Get returns the stored value, or "" if the key is not set
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// OpenSecretStore returns the secret store backend config uses
+func OpenSecretStore(config *models.Config) (SecretStore, error) {
+	switch SecretStoreInUse(config) {
+	case SecretStoreFile:
+		return newFileSecretStore()
+	case SecretStoreKeyring:
+		return newKeyringSecretStore()
+	default:
+		return nil, fmt.Errorf("unknown secret store %q (valid: %s, %s)", config.SecretStore, SecretStoreFile, SecretStoreKeyring)
+	}
+}
+
+// SecretStoreInUse returns the secret store backend config uses. Without a
+// secretStore setting that is the keyring when one is available, so that
+// secrets don't sit next to their key. The encrypted file is used instead
+// when SSHBUDDY_PASSPHRASE is set, when it already holds secrets, and, as a
+// last resort, when there is no keyring.
+func SecretStoreInUse(config *models.Config) string {
+	if config.SecretStore != "" {
+		return config.SecretStore
+	}
+	if os.Getenv(passphraseEnv) != "" || secretsFileExists() || !keyringAvailable() {
+		return SecretStoreFile
+	}
+	return SecretStoreKeyring
+}
+
+// SecretKeyFileInUse reports whether config's secrets go to the encrypted
+// file with its key in a file next to it, because no passphrase is set
+func SecretKeyFileInUse(config *models.Config) bool {
+	return SecretStoreInUse(config) == SecretStoreFile && os.Getenv(passphraseEnv) == ""
+}
+
+// secretsFileExists reports whether the encrypted secrets file exists
+func secretsFileExists() bool {
+	configPath, err := GetDataPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(filepath.Dir(configPath), secretsFileName))
+	return err == nil
+}
+
+// keyringAvailable reports whether the keyring answers, checked once
+var keyringAvailable = sync.OnceValue(func() bool {
+	store, err := newKeyringSecretStore()
+	if err != nil {
+		return false
+	}
+	// A missing item is fine; an error means there's no Secret Service
+	_, err = store.Get("availability-check")
+	if err != nil {
+		slog.Debug("Keyring not available", "err", err)
+	}
+	return err == nil
+})
+
+// secretStoreWarning returns a warning when secrets fell back to the key
+// file for lack of a keyring, and there are tokens to protect
+func secretStoreWarning(config *models.Config) *models.ValidationError {
+	if config.SecretStore != "" || len(config.TermixServers) == 0 || !SecretKeyFileInUse(config) {
+		return nil
+	}
+	return &models.ValidationError{
+		Field:    "secretStore",
+		Message:  "no keyring was found, so Termix tokens are encrypted with a key kept next to them in secrets.key and only file permissions protect them; set SSHBUDDY_PASSPHRASE, or \"secretStore\": \"file\" to accept this",
+		Index:    -1,
+		Severity: models.SeverityWarning,
+	}
+}
+
+// loadSecrets fills in secrets that live in the secret store. Values still
+// present in the config file (written by older versions) take precedence;
+// they are moved into the store on the next save.
+func loadSecrets(config *models.Config) {
+	var store SecretStore
+	for i := range config.TermixServers {
+		server := &config.TermixServers[i]
+		if server.JWT != "" {
+			continue
+		}
+
+		if store == nil {
+			var err error
+			store, err = OpenSecretStore(config)
+			if err != nil {
+				slog.Error("Opening secret store failed", "err", err)
+				return
+			}
+		}
+
+		jwt, err := store.Get(termixJWTKey(server.Name))
+		if err == nil && jwt == "" && server.Name == defaultTermixServerName {
+			jwt, err = store.Get(secretTermixJWTLegacy)
+		}
+		if err != nil {
+			slog.Error("Reading Termix token from secret store failed", "server", server.Name, "err", err)
+			continue
+		}
+		server.JWT = jwt
+	}
+}
+
+// deleteTermixToken removes the stored token of the Termix server called name
+func deleteTermixToken(config *models.Config, name string) error {
+	store, err := OpenSecretStore(config)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{termixJWTKey(name)}
+	if name == defaultTermixServerName {
+		keys = append(keys, secretTermixJWTLegacy)
+	}
+	for _, key := range keys {
+		if err := store.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeSecrets moves secrets from config into the secret store and blanks
+// them, so config is safe to write to disk
+func storeSecrets(config *models.Config) error {
+	var store SecretStore
+	for i := range config.TermixServers {
+		server := &config.TermixServers[i]
+		if server.JWT == "" {
+			continue
+		}
+
+		if store == nil {
+			var err error
+			store, err = OpenSecretStore(config)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := store.Set(termixJWTKey(server.Name), server.JWT); err != nil {
+			return fmt.Errorf("failed to store Termix token for %s: %w", server.Name, err)
+		}
+		if server.Name == defaultTermixServerName {
+			if err := store.Delete(secretTermixJWTLegacy); err != nil {
+				slog.Warn("Removing legacy Termix token failed", "err", err)
+			}
+		}
+		server.JWT = ""
+	}
+	return nil
+}
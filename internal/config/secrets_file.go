@@ -0,0 +1,244 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"sshbuddy/internal/atomicfile"
+)
+
+const (
+	// passphraseEnv supplies the passphrase for the encrypted secrets file.
+	// When unset, a random passphrase is generated and kept in keyFileName
+	// next to it, so the encryption adds nothing over the file permissions.
+	passphraseEnv = "SSHBUDDY_PASSPHRASE"
+
+	secretsFileName = "secrets.enc"
+	keyFileName     = "secrets.key"
+
+	pbkdf2Iterations = 600000
+)
+
+// encryptedSecrets is the on-disk format of the encrypted secrets file
+type encryptedSecrets struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// fileSecretStore keeps secrets in an AES-GCM encrypted file with a
+// PBKDF2-derived key. Without SSHBUDDY_PASSPHRASE the key is in a file next
+// to it, and only the 0600 permissions of both keep other users out.
+type fileSecretStore struct {
+	path       string
+	keyPath    string // Key file, created on the first write; empty with a passphrase
+	passphrase string
+}
+
+// derivedKeys caches PBKDF2 output per salt, since derivation is deliberately slow
+var derivedKeys = struct {
+	sync.Mutex
+	entries map[string][]byte
+}{entries: make(map[string][]byte)}
+
+func newFileSecretStore() (*fileSecretStore, error) {
+	configPath, err := GetDataPath()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(configPath)
+
+	store := &fileSecretStore{
+		path:       filepath.Join(dir, secretsFileName),
+		passphrase: os.Getenv(passphraseEnv),
+	}
+	if store.passphrase == "" {
+		store.keyPath = filepath.Join(dir, keyFileName)
+	}
+	return store, nil
+}
+
+// key returns the passphrase, loading the key file when there's no
+// SSHBUDDY_PASSPHRASE. Reading an empty store never gets here, so the key
+// file is only created once there is something to encrypt.
+func (s *fileSecretStore) key() (string, error) {
+	if s.keyPath == "" || s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	passphrase, err := loadOrCreateKeyFile(s.keyPath)
+	if err != nil {
+		return "", err
+	}
+	s.passphrase = passphrase
+	return passphrase, nil
+}
+
+// loadOrCreateKeyFile returns the generated passphrase, creating it on first use
+func loadOrCreateKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate secrets key: %w", err)
+	}
+	passphrase := hex.EncodeToString(random)
+
+	// O_EXCL so two instances starting together don't overwrite each other's key
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return loadOrCreateKeyFile(path)
+		}
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(passphrase + "\n"); err != nil {
+		return "", err
+	}
+	slog.Warn("Created a key file for the secrets file; only file permissions protect the secrets", "path", path)
+	return passphrase, nil
+}
+
+func (s *fileSecretStore) Get(key string) (string, error) {
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	return secrets[key], nil
+}
+
+func (s *fileSecretStore) Set(key, value string) error {
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	if secrets[key] == value {
+		return nil
+	}
+	secrets[key] = value
+	return s.write(secrets)
+}
+
+func (s *fileSecretStore) Delete(key string) error {
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return s.write(secrets)
+}
+
+// read decrypts the secrets file; a missing file is an empty store
+func (s *fileSecretStore) read() (map[string]string, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var encrypted encryptedSecrets
+	if err := json.Unmarshal(data, &encrypted); err != nil {
+		return nil, fmt.Errorf("secrets file is corrupt: %w", err)
+	}
+
+	gcm, err := s.cipher(encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets (wrong %s?)", passphraseEnv)
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("secrets file is corrupt: %w", err)
+	}
+	return secrets, nil
+}
+
+// write encrypts secrets with a fresh salt and nonce and replaces the file
+func (s *fileSecretStore) write(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(encryptedSecrets{
+		Version:    1,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(s.path, data, 0600)
+}
+
+func (s *fileSecretStore) cipher(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := s.key()
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := hex.EncodeToString(salt) + ":" + passphrase
+
+	derivedKeys.Lock()
+	key, ok := derivedKeys.entries[cacheKey]
+	derivedKeys.Unlock()
+
+	if !ok {
+		key, err = pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+		if err != nil {
+			return nil, err
+		}
+		derivedKeys.Lock()
+		derivedKeys.entries[cacheKey] = key
+		derivedKeys.Unlock()
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
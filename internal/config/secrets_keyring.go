@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keyringService is the Secret Service attribute sshbuddy entries are stored under
+const keyringService = "sshbuddy"
+
+// keyringSecretStore keeps secrets in the desktop keyring through the
+// Secret Service API, using libsecret's secret-tool so no D-Bus bindings are needed
+type keyringSecretStore struct {
+	tool string
+}
+
+func newKeyringSecretStore() (*keyringSecretStore, error) {
+	tool, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("keyring secret store needs secret-tool (libsecret) on PATH: %w", err)
+	}
+	return &keyringSecretStore{tool: tool}, nil
+}
+
+func (s *keyringSecretStore) Get(key string) (string, error) {
+	cmd := exec.Command(s.tool, "lookup", "service", keyringService, "key", key)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits 1 without output when the item doesn't exist
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() == 0 {
+			return "", nil
+		}
+		return "", fmt.Errorf("keyring lookup failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func (s *keyringSecretStore) Set(key, value string) error {
+	// The secret is passed on stdin, never as an argument
+	cmd := exec.Command(s.tool, "store", "--label=sshbuddy "+key, "service", keyringService, "key", key)
+	cmd.Stdin = strings.NewReader(value)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("keyring store failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (s *keyringSecretStore) Delete(key string) error {
+	cmd := exec.Command(s.tool, "clear", "service", keyringService, "key", key)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("keyring clear failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sshbuddy/pkg/models"
+)
+
+func TestSecretStoreInUse(t *testing.T) {
+	path := useTempConfigDir(t)
+	t.Setenv(passphraseEnv, "")
+
+	cfg := &models.Config{SecretStore: SecretStoreKeyring}
+	if got := SecretStoreInUse(cfg); got != SecretStoreKeyring {
+		t.Errorf("explicit keyring: got %q", got)
+	}
+
+	// A passphrase asks for the encrypted file
+	cfg.SecretStore = ""
+	t.Setenv(passphraseEnv, "test passphrase")
+	if got := SecretStoreInUse(cfg); got != SecretStoreFile {
+		t.Errorf("with a passphrase: got %q, want %q", got, SecretStoreFile)
+	}
+	t.Setenv(passphraseEnv, "")
+
+	// Secrets already in the file stay there
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	secretsPath := filepath.Join(filepath.Dir(path), secretsFileName)
+	if err := os.WriteFile(secretsPath, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := SecretStoreInUse(cfg); got != SecretStoreFile {
+		t.Errorf("with an existing secrets file: got %q, want %q", got, SecretStoreFile)
+	}
+	if err := os.Remove(secretsPath); err != nil {
+		t.Fatal(err)
+	}
+
+	want := SecretStoreFile
+	if keyringAvailable() {
+		want = SecretStoreKeyring
+	}
+	if got := SecretStoreInUse(cfg); got != want {
+		t.Errorf("default: got %q, want %q", got, want)
+	}
+}
+
+func TestSecretStoreWarning(t *testing.T) {
+	useTempConfigDir(t)
+	t.Setenv(passphraseEnv, "")
+	if keyringAvailable() {
+		t.Skip("a keyring is available, so the key file isn't used")
+	}
+
+	cfg := &models.Config{}
+	if warning := secretStoreWarning(cfg); warning != nil {
+		t.Errorf("without Termix servers: got %v, want no warning", warning)
+	}
+
+	cfg.TermixServers = []models.TermixConfig{{Name: "work", Enabled: true, BaseURL: "https://termix.example.com/api"}}
+	warning := secretStoreWarning(cfg)
+	if warning == nil || !warning.IsWarning() {
+		t.Fatalf("falling back to the key file: got %v, want a warning", warning)
+	}
+
+	found := false
+	for _, problem := range Validate(cfg) {
+		found = found || problem.Field == warning.Field
+	}
+	if !found {
+		t.Error("Validate doesn't report the secret store warning")
+	}
+
+	// Choosing the file, or setting a passphrase, silences it
+	cfg.SecretStore = SecretStoreFile
+	if warning := secretStoreWarning(cfg); warning != nil {
+		t.Errorf("with secretStore file: got %v, want no warning", warning)
+	}
+	cfg.SecretStore = ""
+	t.Setenv(passphraseEnv, "test passphrase")
+	if warning := secretStoreWarning(cfg); warning != nil {
+		t.Errorf("with a passphrase: got %v, want no warning", warning)
+	}
+}
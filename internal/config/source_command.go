@@ -0,0 +1,268 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"sshbuddy/internal/inventory"
+	"sshbuddy/pkg/models"
+)
+
+// SourceCommand is the kind of command sources ("command:<name>")
+const SourceCommand = "command"
+
+// Defaults for command sources without cacheTtl or timeout
+const (
+	defaultCommandCacheTTL = 5 * time.Minute
+	defaultCommandTimeout  = 30 * time.Second
+)
+
+var commandSourceKind = SourceKind{
+	Kind:  SourceCommand,
+	Label: "Command (prints a JSON host list)",
+	Sources: func(config *models.Config) []HostSource {
+		sources := make([]HostSource, len(config.Commands))
+		for i := range config.Commands {
+			sources[i] = &commandSource{config: config, index: i}
+		}
+		return sources
+	},
+	Add: func(config *models.Config) HostSource {
+		name := SourceCommand
+		for n := 2; findCommandSource(config, name) != nil; n++ {
+			name = fmt.Sprintf("%s%d", SourceCommand, n)
+		}
+		config.Commands = append(config.Commands, models.CommandSourceConfig{
+			Name:    name,
+			Enabled: true,
+		})
+		return &commandSource{config: config, index: len(config.Commands) - 1}
+	},
+}
+
+func findCommandSource(config *models.Config, name string) *models.CommandSourceConfig {
+	for i := range config.Commands {
+		if config.Commands[i].Name == name {
+			return &config.Commands[i]
+		}
+	}
+	return nil
+}
+
+// commandSource runs a local command and reads hosts from its output,
+// read-only. Output is cached for the configured TTL.
+type commandSource struct {
+	config *models.Config
+	index  int // Index into config.Commands
+}
+
+func (s *commandSource) command() *models.CommandSourceConfig {
+	return &s.config.Commands[s.index]
+}
+
+func (s *commandSource) Name() string {
+	return SourceCommand + ":" + s.command().Name
+}
+
+func (s *commandSource) Load() SourceResult {
+	cmd := *s.command()
+	result := SourceResult{Source: s.Name()}
+
+	cache, _ := loadCommandCache(cmd.Name, cmd.Command)
+	if cache != nil && time.Since(cache.FetchedAt) < parseDuration(cmd.CacheTTL, defaultCommandCacheTTL) {
+		result.Hosts = s.tag(cache.Hosts)
+		return result
+	}
+
+	hosts, err := runHostCommand(cmd.Command, parseDuration(cmd.Timeout, defaultCommandTimeout))
+	if err != nil {
+		slog.Error("Running host command failed", "source", result.Source, "err", err)
+		if cache == nil {
+			// The TUI prefixes the source name, so return the error as is
+			result.Err = err
+			return result
+		}
+		for i := range cache.Hosts {
+			cache.Hosts[i].Stale = true
+		}
+		result.Hosts = s.tag(cache.Hosts)
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"%s failed (%v) - showing %d cached host(s) from %s",
+			result.Source, err, len(cache.Hosts), formatAge(time.Since(cache.FetchedAt))))
+		return result
+	}
+
+	if err := saveCommandCache(cmd.Name, cmd.Command, hosts); err != nil {
+		slog.Warn("Saving command cache failed", "source", result.Source, "err", err)
+	}
+	result.Hosts = s.tag(hosts)
+	return result
+}
+
+// tag marks hosts as coming from this source
+func (s *commandSource) tag(hosts []models.Host) []models.Host {
+	name := s.Name()
+	for i := range hosts {
+		hosts[i].Source = name
+	}
+	return hosts
+}
+
+func (s *commandSource) Writable() bool {
+	return false
+}
+
+func (s *commandSource) Save(hosts []models.Host) error {
+	return ErrReadOnly
+}
+
+func (s *commandSource) Label() string {
+	return "Command: " + s.command().Name
+}
+
+func (s *commandSource) Description() string {
+	if s.command().Command == "" {
+		return "Hosts printed by a local command"
+	}
+	return "Hosts from `" + s.command().Command + "`"
+}
+
+func (s *commandSource) Enabled() bool {
+	cmd := s.command()
+	return cmd.Enabled && strings.TrimSpace(cmd.Command) != ""
+}
+
+func (s *commandSource) SetEnabled(enabled bool) error {
+	if enabled && strings.TrimSpace(s.command().Command) == "" {
+		return fmt.Errorf("set a command before enabling this source")
+	}
+	s.command().Enabled = enabled
+	return nil
+}
+
+func (s *commandSource) Fields() []SourceField {
+	cmd := s.command()
+	return []SourceField{
+		{
+			Label:       "Name",
+			Value:       cmd.Name,
+			Placeholder: "cmdb",
+			Hint:        "Shown as the host source; letters, digits, '-' and '_'",
+		},
+		{
+			Label:       "Command",
+			Value:       cmd.Command,
+			Placeholder: "cmdb-export --format json",
+			Hint:        "Run with the shell; must print a JSON host list on stdout",
+		},
+		{
+			Label:       "Cache TTL",
+			Value:       cmd.CacheTTL,
+			Placeholder: "5m",
+			Hint:        "How long output is reused before running again (0 to always run)",
+		},
+		{
+			Label:       "Timeout",
+			Value:       cmd.Timeout,
+			Placeholder: "30s",
+			Hint:        "Maximum time the command may run",
+		},
+	}
+}
+
+func (s *commandSource) SetFields(values []string) error {
+	cmd := *s.command()
+	cmd.Name = strings.TrimSpace(values[0])
+	cmd.Command = strings.TrimSpace(values[1])
+	cmd.CacheTTL = strings.TrimSpace(values[2])
+	cmd.Timeout = strings.TrimSpace(values[3])
+
+	if errs := cmd.Validate(); len(errs) > 0 {
+		return fmt.Errorf("%s", errs[0].Message)
+	}
+	for i, other := range s.config.Commands {
+		if i != s.index && other.Name == cmd.Name {
+			return fmt.Errorf("a command source named '%s' already exists", cmd.Name)
+		}
+	}
+
+	*s.command() = cmd
+	return nil
+}
+
+func (s *commandSource) Remove() {
+	s.config.Commands = append(s.config.Commands[:s.index], s.config.Commands[s.index+1:]...)
+}
+
+// parseDuration parses a configured duration, using fallback when it is
+// empty or invalid. "0" means zero.
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	if value == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return fallback
+	}
+	return d
+}
+
+// runHostCommand runs command with the shell and parses its stdout as a
+// JSON host list. Failures include the exit status and stderr.
+func runHostCommand(command string, timeout time.Duration) ([]models.Host, error) {
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait for children of the shell that still hold the output open
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("command timed out after %s", timeout)
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if msg := lastLine(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("command exited with status %d: %s", exitErr.ExitCode(), msg)
+			}
+			return nil, fmt.Errorf("command exited with status %d", exitErr.ExitCode())
+		}
+		return nil, fmt.Errorf("failed to run command: %w", err)
+	}
+
+	hosts, err := inventory.ParseHostList(stdout.Bytes(), false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command output: %w", err)
+	}
+	return hosts, nil
+}
+
+// lastLine returns the last non-empty line of s, which is usually the
+// actual error in a command's stderr
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
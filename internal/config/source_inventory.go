@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"sshbuddy/internal/inventory"
+	"sshbuddy/pkg/models"
+)
+
+// SourceInventory is the kind of inventory file sources ("inventory:<name>")
+const SourceInventory = "inventory"
+
+var inventorySourceKind = SourceKind{
+	Kind:  SourceInventory,
+	Label: "Inventory file (Ansible, CSV, JSON/YAML)",
+	Sources: func(config *models.Config) []HostSource {
+		sources := make([]HostSource, len(config.Inventories))
+		for i := range config.Inventories {
+			sources[i] = &inventorySource{config: config, index: i}
+		}
+		return sources
+	},
+	Add: func(config *models.Config) HostSource {
+		name := SourceInventory
+		for n := 2; findInventory(config, name) != nil; n++ {
+			name = fmt.Sprintf("%s%d", SourceInventory, n)
+		}
+		config.Inventories = append(config.Inventories, models.InventoryConfig{
+			Name:    name,
+			Enabled: true,
+		})
+		return &inventorySource{config: config, index: len(config.Inventories) - 1}
+	},
+}
+
+func findInventory(config *models.Config, name string) *models.InventoryConfig {
+	for i := range config.Inventories {
+		if config.Inventories[i].Name == name {
+			return &config.Inventories[i]
+		}
+	}
+	return nil
+}
+
+// inventorySource reads hosts from an inventory file, read-only
+type inventorySource struct {
+	config *models.Config
+	index  int // Index into config.Inventories
+}
+
+func (s *inventorySource) inventory() *models.InventoryConfig {
+	return &s.config.Inventories[s.index]
+}
+
+func (s *inventorySource) Name() string {
+	return SourceInventory + ":" + s.inventory().Name
+}
+
+func (s *inventorySource) Load() SourceResult {
+	inv := s.inventory()
+	result := SourceResult{Source: s.Name()}
+
+	hosts, warnings, err := inventory.Load(inv.Path, inv.Format)
+	if err != nil {
+		slog.Error("Loading inventory failed", "source", result.Source, "err", err)
+		// The TUI prefixes the source name, so return the error as is
+		result.Err = err
+		return result
+	}
+
+	for _, warning := range warnings {
+		slog.Warn("Skipped inventory entry", "source", result.Source, "warning", warning)
+	}
+
+	for i := range hosts {
+		hosts[i].Source = result.Source
+	}
+	result.Hosts = hosts
+	result.Warnings = warnings
+	return result
+}
+
+func (s *inventorySource) Writable() bool {
+	return false
+}
+
+func (s *inventorySource) Save(hosts []models.Host) error {
+	return ErrReadOnly
+}
+
+func (s *inventorySource) Label() string {
+	return "Inventory: " + s.inventory().Name
+}
+
+func (s *inventorySource) Description() string {
+	inv := s.inventory()
+	if inv.Path == "" {
+		return "Hosts from an inventory file"
+	}
+	format := inv.Format
+	if format == "" {
+		format = "auto"
+	}
+	return fmt.Sprintf("Hosts from %s (%s)", inv.Path, format)
+}
+
+func (s *inventorySource) Enabled() bool {
+	inv := s.inventory()
+	return inv.Enabled && inv.Path != ""
+}
+
+func (s *inventorySource) SetEnabled(enabled bool) error {
+	if enabled && s.inventory().Path == "" {
+		return fmt.Errorf("set a path before enabling this inventory")
+	}
+	s.inventory().Enabled = enabled
+	return nil
+}
+
+func (s *inventorySource) Fields() []SourceField {
+	inv := s.inventory()
+	return []SourceField{
+		{
+			Label:       "Name",
+			Value:       inv.Name,
+			Placeholder: "ansible",
+			Hint:        "Shown as the host source; letters, digits, '-' and '_'",
+		},
+		{
+			Label:       "Path",
+			Value:       inv.Path,
+			Placeholder: "~/infra/inventory/hosts",
+			Hint:        "Inventory file to read hosts from",
+		},
+		{
+			Label:       "Format",
+			Value:       inv.Format,
+			Placeholder: "auto",
+			Hint:        "Leave empty to guess from the file, or: " + strings.Join(inventory.Formats, ", "),
+		},
+	}
+}
+
+func (s *inventorySource) SetFields(values []string) error {
+	inv := *s.inventory()
+	inv.Name = strings.TrimSpace(values[0])
+	inv.Path = strings.TrimSpace(values[1])
+	inv.Format = strings.TrimSpace(values[2])
+	if inv.Format == "auto" {
+		inv.Format = inventory.FormatAuto
+	}
+
+	if errs := inv.Validate(); len(errs) > 0 {
+		return fmt.Errorf("%s", errs[0].Message)
+	}
+	if inv.Format != inventory.FormatAuto {
+		known := false
+		for _, format := range inventory.Formats {
+			known = known || inv.Format == format
+		}
+		if !known {
+			return fmt.Errorf("unknown format '%s' (valid: %s)", inv.Format, strings.Join(inventory.Formats, ", "))
+		}
+	}
+	for i, other := range s.config.Inventories {
+		if i != s.index && other.Name == inv.Name {
+			return fmt.Errorf("an inventory named '%s' already exists", inv.Name)
+		}
+	}
+
+	*s.inventory() = inv
+	return nil
+}
+
+func (s *inventorySource) Remove() {
+	s.config.Inventories = append(s.config.Inventories[:s.index], s.config.Inventories[s.index+1:]...)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"sshbuddy/pkg/models"
+)
+
+var manualSourceKind = SourceKind{
+	Kind:  SourceManual,
+	Label: "SSHBuddy",
+	Sources: func(config *models.Config) []HostSource {
+		return []HostSource{&manualSource{config: config}}
+	},
+}
+
+// manualSource serves the hosts added through SSHBuddy, kept in config.json
+type manualSource struct {
+	config *models.Config
+}
+
+func (s *manualSource) Name() string {
+	return SourceManual
+}
+
+func (s *manualSource) Load() SourceResult {
+	hosts := make([]models.Host, 0, len(s.config.Hosts))
+	for _, host := range s.config.Hosts {
+		if host.Source == "" {
+			host.Source = SourceManual
+		}
+		// Skip hosts merged in from other sources
+		if host.Source != SourceManual {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return SourceResult{Source: SourceManual, Hosts: hosts}
+}
+
+func (s *manualSource) Writable() bool {
+	return true
+}
+
+func (s *manualSource) Save(hosts []models.Host) error {
+	saved := make([]models.Host, len(hosts))
+	for i, host := range hosts {
+		host.Source = SourceManual
+		saved[i] = host
+	}
+	err := UpdateConfig(func(config *models.Config) error {
+		config.Hosts = saved
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.config.Hosts = saved
+	return nil
+}
+
+func (s *manualSource) Label() string {
+	return "SSHBuddy"
+}
+
+func (s *manualSource) Description() string {
+	return "Hosts added manually through SSHBuddy"
+}
+
+func (s *manualSource) Enabled() bool {
+	return s.config.Sources.SSHBuddyEnabled
+}
+
+func (s *manualSource) SetEnabled(enabled bool) error {
+	s.config.Sources.SSHBuddyEnabled = enabled
+	return nil
+}
+
+func (s *manualSource) Fields() []SourceField {
+	return nil
+}
+
+func (s *manualSource) SetFields(values []string) error {
+	return nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+
+	"sshbuddy/internal/ssh"
+	"sshbuddy/pkg/models"
+)
+
+var sshConfigSourceKind = SourceKind{
+	Kind:  SourceSSHConfig,
+	Label: "SSH Config",
+	Sources: func(config *models.Config) []HostSource {
+		return []HostSource{&sshConfigSource{config: config}}
+	},
+}
+
+// sshConfigSource reads hosts from an OpenSSH client config file. Hosts are
+// read-only; edit the file itself to change them.
+type sshConfigSource struct {
+	config *models.Config
+}
+
+func (s *sshConfigSource) Name() string {
+	return SourceSSHConfig
+}
+
+func (s *sshConfigSource) Load() SourceResult {
+	sshHosts, err := ssh.LoadHostsFromSSHConfigFile(s.config.SSH.ConfigPath)
+	if err != nil {
+		slog.Error("Loading SSH config failed", "err", err)
+		return SourceResult{Source: SourceSSHConfig, Err: err}
+	}
+
+	// Mark SSH config hosts
+	for i := range sshHosts {
+		sshHosts[i].Source = SourceSSHConfig
+	}
+
+	return SourceResult{Source: SourceSSHConfig, Hosts: sshHosts}
+}
+
+func (s *sshConfigSource) Writable() bool {
+	return false
+}
+
+func (s *sshConfigSource) Save(hosts []models.Host) error {
+	return ErrReadOnly
+}
+
+func (s *sshConfigSource) Label() string {
+	return "SSH Config"
+}
+
+func (s *sshConfigSource) Description() string {
+	if s.config.SSH.ConfigPath != "" {
+		return "Hosts from " + s.config.SSH.ConfigPath
+	}
+	return "Hosts from ~/.ssh/config"
+}
+
+func (s *sshConfigSource) Enabled() bool {
+	return s.config.Sources.SSHConfigEnabled && s.config.SSH.Enabled
+}
+
+func (s *sshConfigSource) SetEnabled(enabled bool) error {
+	s.config.Sources.SSHConfigEnabled = enabled
+	if enabled {
+		s.config.SSH.Enabled = true
+	}
+	return nil
+}
+
+func (s *sshConfigSource) Fields() []SourceField {
+	return []SourceField{
+		{
+			Label:       "Config Path",
+			Value:       s.config.SSH.ConfigPath,
+			Placeholder: "~/.ssh/config (leave empty for default)",
+			Hint:        "Path to SSH config file (leave empty for default ~/.ssh/config)",
+		},
+	}
+}
+
+func (s *sshConfigSource) SetFields(values []string) error {
+	s.config.SSH.ConfigPath = strings.TrimSpace(values[0])
+	return nil
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"sshbuddy/internal/termix"
+	"sshbuddy/pkg/models"
+)
+
+// defaultTermixServerName is given to the unnamed server of older config files
+const defaultTermixServerName = "termix"
+
+var termixSourceKind = SourceKind{
+	Kind:  SourceTermix,
+	Label: "Termix server",
+	Sources: func(config *models.Config) []HostSource {
+		sources := make([]HostSource, len(config.TermixServers))
+		for i := range config.TermixServers {
+			sources[i] = &termixSource{config: config, index: i}
+		}
+		return sources
+	},
+	Add: func(config *models.Config) HostSource {
+		name := defaultTermixServerName
+		for n := 2; FindTermixServer(config, name) != nil; n++ {
+			name = fmt.Sprintf("%s%d", defaultTermixServerName, n)
+		}
+		config.TermixServers = append(config.TermixServers, models.TermixConfig{
+			Name:    name,
+			Enabled: true,
+		})
+		return &termixSource{config: config, index: len(config.TermixServers) - 1}
+	},
+}
+
+// TermixSource returns the source name for the Termix server called name
+func TermixSource(name string) string {
+	return SourceTermix + ":" + name
+}
+
+// TermixServerName returns the server name of a Termix source
+func TermixServerName(source string) (string, bool) {
+	return strings.CutPrefix(source, SourceTermix+":")
+}
+
+// FindTermixServer returns the Termix server called name, or nil
+func FindTermixServer(config *models.Config, name string) *models.TermixConfig {
+	for i := range config.TermixServers {
+		if config.TermixServers[i].Name == name {
+			return &config.TermixServers[i]
+		}
+	}
+	return nil
+}
+
+// termixSource serves the hosts of one Termix server, read-only
+type termixSource struct {
+	config  *models.Config
+	index   int    // Index into config.TermixServers
+	oldName string // Name the cache and token are stored under, until Commit
+	removed bool   // Remove was called; Commit deletes the cache and token
+}
+
+func (s *termixSource) server() *models.TermixConfig {
+	return &s.config.TermixServers[s.index]
+}
+
+func (s *termixSource) Name() string {
+	return TermixSource(s.server().Name)
+}
+
+func (s *termixSource) Load() SourceResult {
+	return loadTermixHosts(*s.server())
+}
+
+func (s *termixSource) Writable() bool {
+	return false
+}
+
+func (s *termixSource) Save(hosts []models.Host) error {
+	return ErrReadOnly
+}
+
+func (s *termixSource) Label() string {
+	return "Termix: " + s.server().Name
+}
+
+func (s *termixSource) Description() string {
+	server := s.server()
+	description := "Hosts from Termix API server"
+	if server.BaseURL != "" {
+		description = "Hosts from " + server.BaseURL
+	}
+	if server.AliasPrefix != "" {
+		description += fmt.Sprintf(" (aliases prefixed '%s')", server.AliasPrefix)
+	}
+	return description
+}
+
+func (s *termixSource) Enabled() bool {
+	server := s.server()
+	return server.Enabled && server.BaseURL != ""
+}
+
+func (s *termixSource) SetEnabled(enabled bool) error {
+	if enabled && s.server().BaseURL == "" {
+		return fmt.Errorf("set a base URL before enabling this server")
+	}
+	s.server().Enabled = enabled
+	return nil
+}
+
+func (s *termixSource) Fields() []SourceField {
+	server := s.server()
+	return []SourceField{
+		{
+			Label:       "Name",
+			Value:       server.Name,
+			Placeholder: "prod",
+			Hint:        "Shown as the host source; letters, digits, '-' and '_'",
+		},
+		{
+			Label:       "Base URL",
+			Value:       server.BaseURL,
+			Placeholder: "https://termix.example.com/api",
+			Hint:        "API endpoint (e.g., https://termix.example.com/api)",
+		},
+		{
+			Label:       "Alias Prefix",
+			Value:       server.AliasPrefix,
+			Placeholder: "prod- (optional)",
+			Hint:        "Prepended to host aliases, keeps servers apart (e.g., prod-)",
+		},
+	}
+}
+
+func (s *termixSource) SetFields(values []string) error {
+	server := *s.server()
+	server.Name = strings.TrimSpace(values[0])
+	server.BaseURL = strings.TrimSpace(values[1])
+	server.AliasPrefix = strings.TrimSpace(values[2])
+
+	if errs := server.Validate(); len(errs) > 0 {
+		return fmt.Errorf("%s", errs[0].Message)
+	}
+	for i, other := range s.config.TermixServers {
+		if i != s.index && other.Name == server.Name {
+			return fmt.Errorf("a Termix server named '%s' already exists", server.Name)
+		}
+	}
+
+	if oldName := s.server().Name; oldName != server.Name {
+		if s.oldName == "" {
+			s.oldName = oldName
+		}
+		renameTermixServer(s.config, oldName, server.Name)
+	}
+	*s.server() = server
+	return nil
+}
+
+func (s *termixSource) Remove() {
+	if s.oldName == "" {
+		s.oldName = s.server().Name
+	}
+	s.removed = true
+	s.config.TermixServers = append(s.config.TermixServers[:s.index], s.config.TermixServers[s.index+1:]...)
+}
+
+// Commit moves the host cache and token of a renamed server to its new name,
+// or deletes them for a removed one, so a server added later under the old
+// name doesn't inherit them. The token is already saved under the new name,
+// since it travels with the server in the config.
+func (s *termixSource) Commit() {
+	oldName := s.oldName
+	if oldName == "" {
+		return
+	}
+	s.oldName = ""
+
+	if s.removed {
+		if err := removeTermixCache(oldName); err != nil {
+			slog.Warn("Removing Termix cache failed", "server", oldName, "err", err)
+		}
+	} else if newName := s.server().Name; newName != oldName {
+		if err := renameTermixCache(oldName, newName); err != nil {
+			slog.Warn("Moving Termix cache failed", "from", oldName, "to", newName, "err", err)
+		}
+	} else {
+		return
+	}
+	if err := deleteTermixToken(s.config, oldName); err != nil {
+		slog.Warn("Removing Termix token failed", "server", oldName, "err", err)
+	}
+}
+
+// renameTermixServer moves the overlays and precedence entries of a Termix
+// server to its new name. Its cache and token follow in Commit.
+func renameTermixServer(config *models.Config, oldName, newName string) {
+	oldSource, newSource := TermixSource(oldName), TermixSource(newName)
+	for i := range config.Overlays {
+		if config.Overlays[i].Source == oldSource {
+			config.Overlays[i].Source = newSource
+		}
+	}
+	for i := range config.Merge.Precedence {
+		if config.Merge.Precedence[i] == oldSource {
+			config.Merge.Precedence[i] = newSource
+		}
+	}
+}
+
+func loadTermixHosts(termixConfig models.TermixConfig) SourceResult {
+	result := SourceResult{Source: TermixSource(termixConfig.Name)}
+	slog.Debug("Loading Termix hosts", "server", termixConfig.Name, "baseUrl", termixConfig.BaseURL)
+
+	client := termix.NewClient(termixConfig.BaseURL, termixConfig.JWT, termixConfig.JWTExpiry)
+
+	// Try to fetch hosts without credentials first (using cached token)
+	termixHosts, termixFetchErr := client.FetchHosts("", "")
+
+	if termixFetchErr != nil {
+		// Auth errors are returned as-is so the TUI can prompt for credentials
+		if _, isAuthError := termixFetchErr.(*termix.AuthError); isAuthError {
+			result.Err = termixFetchErr
+			return result
+		}
+
+		slog.Error("Fetching Termix hosts failed", "server", termixConfig.Name, "err", termixFetchErr)
+
+		// Fall back to the last successful fetch if we have one
+		cache, cacheErr := loadTermixCache(termixConfig.Name, termixConfig.BaseURL)
+		if cacheErr != nil {
+			configPath, _ := GetDataPath()
+			result.Err = fmt.Errorf("%w\n\nCheck your Termix configuration at: %s", termixFetchErr, configPath)
+			return result
+		}
+
+		slog.Info("Using cached Termix hosts", "server", termixConfig.Name, "count", len(cache.Hosts), "fetchedAt", cache.FetchedAt)
+		result.Hosts = applyTermixServer(cache.Hosts, termixConfig)
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"Termix server %s unreachable - showing %d cached host(s) from %s",
+			termixConfig.Name, len(cache.Hosts), formatAge(time.Since(cache.FetchedAt))))
+		return result
+	}
+
+	slog.Info("Fetched Termix hosts", "server", termixConfig.Name, "count", len(termixHosts))
+	if err := saveTermixCache(termixConfig.Name, termixConfig.BaseURL, termixHosts); err != nil {
+		slog.Warn("Saving Termix cache failed", "server", termixConfig.Name, "err", err)
+	} else {
+		// The generated ssh_config reads Termix hosts from the cache
+		refreshManagedSSHConfig()
+	}
+	result.Hosts = applyTermixServer(termixHosts, termixConfig)
+
+	// Persist the JWT token and expiry if they were updated
+	if client.GetJWT() != termixConfig.JWT || client.GetJWTExpiry() != termixConfig.JWTExpiry {
+		if err := saveTermixToken(termixConfig.Name, client.GetJWT(), client.GetJWTExpiry()); err != nil {
+			slog.Error("Saving Termix token failed", "server", termixConfig.Name, "err", err)
+		}
+	}
+
+	return result
+}
+
+// applyTermixServer tags hosts with their server's source name and alias prefix.
+// The cache keeps hosts as fetched, so prefix changes apply to cached hosts too.
+func applyTermixServer(hosts []models.Host, server models.TermixConfig) []models.Host {
+	for i := range hosts {
+		hosts[i].Source = TermixSource(server.Name)
+		hosts[i].Alias = server.AliasPrefix + hosts[i].Alias
+	}
+	return hosts
+}
+
+// saveTermixToken stores a refreshed token without touching anything else
+func saveTermixToken(name, jwt string, expiry int64) error {
+	return UpdateConfig(func(config *models.Config) error {
+		server := FindTermixServer(config, name)
+		if server == nil {
+			return fmt.Errorf("termix server %q is no longer configured", name)
+		}
+		server.JWT = jwt
+		server.JWTExpiry = expiry
+		return nil
+	})
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"sshbuddy/pkg/models"
+)
+
+// termixState returns the token stored for the Termix server called name and
+// whether its host cache exists
+func termixState(t *testing.T, name string) (string, bool) {
+	t.Helper()
+	store, err := OpenSecretStore(&models.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := store.Get(termixJWTKey(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := termixCachePath(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(path)
+	return jwt, err == nil
+}
+
+// editTermixServer applies change to the source of the Termix server called
+// name in the saved config and returns the source
+func editTermixServer(t *testing.T, name string, change func(source ConfigurableSource) error) (ConfigurableSource, error) {
+	t.Helper()
+	var edited ConfigurableSource
+	err := UpdateConfig(func(cfg *models.Config) error {
+		edited = FindSource(cfg, TermixSource(name)).(ConfigurableSource)
+		return change(edited)
+	})
+	return edited, err
+}
+
+func TestTermixRenameAndRemove(t *testing.T) {
+	useTempConfigDir(t)
+	t.Setenv(passphraseEnv, "test passphrase")
+
+	const url = "https://termix.example.com/api"
+	err := UpdateConfig(func(cfg *models.Config) error {
+		cfg.TermixServers = []models.TermixConfig{{Name: "old", Enabled: true, BaseURL: url, JWT: "token"}}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveTermixCache("old", url, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A rename whose save fails leaves the cache and token alone
+	saveFailed := errors.New("save failed")
+	_, err = editTermixServer(t, "old", func(source ConfigurableSource) error {
+		if err := source.SetFields([]string{"new", url, ""}); err != nil {
+			return err
+		}
+		return saveFailed
+	})
+	if !errors.Is(err, saveFailed) {
+		t.Fatalf("UpdateConfig: got %v, want %v", err, saveFailed)
+	}
+	if jwt, cached := termixState(t, "old"); jwt != "token" || !cached {
+		t.Errorf("after a failed rename: old token %q, cached %v; want both kept", jwt, cached)
+	}
+	if jwt, cached := termixState(t, "new"); jwt != "" || cached {
+		t.Errorf("after a failed rename: new token %q, cached %v; want neither", jwt, cached)
+	}
+
+	// A saved rename moves them once committed
+	source, err := editTermixServer(t, "old", func(source ConfigurableSource) error {
+		return source.SetFields([]string{"new", url, ""})
+	})
+	if err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	source.(CommittableSource).Commit()
+	if jwt, cached := termixState(t, "old"); jwt != "" || cached {
+		t.Errorf("after renaming: old token %q, cached %v; want neither", jwt, cached)
+	}
+	if jwt, cached := termixState(t, "new"); jwt != "token" || !cached {
+		t.Errorf("after renaming: new token %q, cached %v; want both", jwt, cached)
+	}
+
+	// Removing deletes them, so a new server with the name starts afresh
+	source, err = editTermixServer(t, "new", func(source ConfigurableSource) error {
+		source.(RemovableSource).Remove()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	source.(CommittableSource).Commit()
+	if jwt, cached := termixState(t, "new"); jwt != "" || cached {
+		t.Errorf("after removing: token %q, cached %v; want neither", jwt, cached)
+	}
+}
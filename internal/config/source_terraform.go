@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"sshbuddy/internal/terraform"
+	"sshbuddy/pkg/models"
+)
+
+// SourceTerraform is the kind of Terraform state sources ("terraform:<name>")
+const SourceTerraform = "terraform"
+
+var terraformSourceKind = SourceKind{
+	Kind:  SourceTerraform,
+	Label: "Terraform state files",
+	Sources: func(config *models.Config) []HostSource {
+		sources := make([]HostSource, len(config.Terraform))
+		for i := range config.Terraform {
+			sources[i] = &terraformSource{config: config, index: i}
+		}
+		return sources
+	},
+	Add: func(config *models.Config) HostSource {
+		name := SourceTerraform
+		for n := 2; findTerraformSource(config, name) != nil; n++ {
+			name = fmt.Sprintf("%s%d", SourceTerraform, n)
+		}
+		config.Terraform = append(config.Terraform, models.TerraformConfig{
+			Name:    name,
+			Enabled: true,
+		})
+		return &terraformSource{config: config, index: len(config.Terraform) - 1}
+	},
+}
+
+func findTerraformSource(config *models.Config, name string) *models.TerraformConfig {
+	for i := range config.Terraform {
+		if config.Terraform[i].Name == name {
+			return &config.Terraform[i]
+		}
+	}
+	return nil
+}
+
+// terraformSource reads compute instances from Terraform state files,
+// read-only
+type terraformSource struct {
+	config *models.Config
+	index  int // Index into config.Terraform
+}
+
+func (s *terraformSource) terraform() *models.TerraformConfig {
+	return &s.config.Terraform[s.index]
+}
+
+func (s *terraformSource) Name() string {
+	return SourceTerraform + ":" + s.terraform().Name
+}
+
+func (s *terraformSource) Load() SourceResult {
+	tf := s.terraform()
+	result := SourceResult{Source: s.Name()}
+
+	loaded, err := terraform.Load(tf.StateFiles, terraform.Options{
+		Mappings:  tf.Mappings,
+		User:      tf.User,
+		PrivateIP: tf.Address == "private",
+	})
+	if err != nil {
+		slog.Error("Loading Terraform state failed", "source", result.Source, "err", err)
+		// The TUI prefixes the source name, so return the error as is
+		result.Err = err
+		return result
+	}
+
+	for i := range loaded.Hosts {
+		loaded.Hosts[i].Source = result.Source
+	}
+	for _, warning := range loaded.Warnings {
+		result.Warnings = append(result.Warnings, result.Source+": "+warning)
+	}
+	result.Hosts = loaded.Hosts
+	return result
+}
+
+func (s *terraformSource) Writable() bool {
+	return false
+}
+
+func (s *terraformSource) Save(hosts []models.Host) error {
+	return ErrReadOnly
+}
+
+func (s *terraformSource) Label() string {
+	return "Terraform: " + s.terraform().Name
+}
+
+func (s *terraformSource) Description() string {
+	tf := s.terraform()
+	if len(tf.StateFiles) == 0 {
+		return "Instances from Terraform state files"
+	}
+	return "Instances from " + strings.Join(tf.StateFiles, ", ")
+}
+
+func (s *terraformSource) Enabled() bool {
+	tf := s.terraform()
+	return tf.Enabled && len(tf.StateFiles) > 0
+}
+
+func (s *terraformSource) SetEnabled(enabled bool) error {
+	if enabled && len(s.terraform().StateFiles) == 0 {
+		return fmt.Errorf("set state files before enabling this source")
+	}
+	s.terraform().Enabled = enabled
+	return nil
+}
+
+func (s *terraformSource) Fields() []SourceField {
+	tf := s.terraform()
+	return []SourceField{
+		{
+			Label:       "Name",
+			Value:       tf.Name,
+			Placeholder: "infra",
+			Hint:        "Shown as the host source; letters, digits, '-' and '_'",
+		},
+		{
+			Label:       "State Files",
+			Value:       strings.Join(tf.StateFiles, ", "),
+			Placeholder: "~/infra/terraform.tfstate, ~/infra/terraform.tfstate.d/*/terraform.tfstate",
+			Hint:        "Comma-separated paths; globs are allowed",
+		},
+		{
+			Label:       "User",
+			Value:       tf.User,
+			Placeholder: "ubuntu",
+			Hint:        "SSH user for every instance (leave empty for the ssh default)",
+		},
+		{
+			Label:       "Address",
+			Value:       tf.Address,
+			Placeholder: "public",
+			Hint:        "Connect to the public or private IP",
+		},
+	}
+}
+
+func (s *terraformSource) SetFields(values []string) error {
+	tf := *s.terraform()
+	tf.Name = strings.TrimSpace(values[0])
+	tf.StateFiles = nil
+	for _, path := range strings.Split(values[1], ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			tf.StateFiles = append(tf.StateFiles, path)
+		}
+	}
+	tf.User = strings.TrimSpace(values[2])
+	tf.Address = strings.ToLower(strings.TrimSpace(values[3]))
+
+	if errs := tf.Validate(); len(errs) > 0 {
+		return fmt.Errorf("%s", errs[0].Message)
+	}
+	for i, other := range s.config.Terraform {
+		if i != s.index && other.Name == tf.Name {
+			return fmt.Errorf("a Terraform source named '%s' already exists", tf.Name)
+		}
+	}
+
+	*s.terraform() = tf
+	return nil
+}
+
+func (s *terraformSource) Remove() {
+	s.config.Terraform = append(s.config.Terraform[:s.index], s.config.Terraform[s.index+1:]...)
+}
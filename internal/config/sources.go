@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sshbuddy/internal/termix"
+	"sshbuddy/pkg/models"
+)
+
+// Source names, as stored in models.Host.Source. Sources with several
+// instances are named "<kind>:<instance>", e.g. "termix:prod".
+const (
+	SourceManual    = "manual"
+	SourceSSHConfig = "ssh-config"
+	SourceTermix    = "termix"
+)
+
+// ErrReadOnly is returned when saving hosts to a source that can't be written
+var ErrReadOnly = errors.New("source is read-only")
+
+// HostSource is a place hosts come from
+type HostSource interface {
+	// Name is the unique source name, as stored in models.Host.Source
+	Name() string
+	// Load fetches the source's hosts. It must not modify the config the
+	// source was built from, so sources can load concurrently.
+	Load() SourceResult
+	// Writable reports whether hosts can be added, edited and deleted
+	Writable() bool
+	// Save replaces all hosts of the source. Read-only sources return ErrReadOnly.
+	Save(hosts []models.Host) error
+}
+
+// SourceField is one editable setting of a source
+type SourceField struct {
+	Label       string
+	Value       string
+	Placeholder string
+	Hint        string
+}
+
+// ConfigurableSource is a HostSource shown in the settings screen. Changes
+// are applied to the config the source was built from; callers save it.
+type ConfigurableSource interface {
+	HostSource
+	Label() string
+	Description() string
+	Enabled() bool
+	SetEnabled(enabled bool) error
+	// Fields returns the editable settings, or nil if there are none
+	Fields() []SourceField
+	// SetFields validates and applies values, in the order of Fields
+	SetFields(values []string) error
+}
+
+// RemovableSource is a source that can be deleted from the config
+type RemovableSource interface {
+	Remove()
+}
+
+// CommittableSource is a source whose settings live partly outside the
+// config file, such as a host cache or a stored token. SetFields and Remove
+// only change the config; Commit brings the rest in line once it's saved.
+type CommittableSource interface {
+	Commit()
+}
+
+// SourceKind describes one type of host source
+type SourceKind struct {
+	Kind  string // Source name, or prefix for sources with several instances
+	Label string // Shown when adding a source of this kind
+	// Sources returns the configured sources of this kind, enabled or not
+	Sources func(config *models.Config) []HostSource
+	// Add creates a new source of this kind in config. Nil for kinds with a
+	// fixed set of sources.
+	Add func(config *models.Config) HostSource
+}
+
+// sourceKinds is the registry of source kinds, in priority order
+var sourceKinds = []SourceKind{
+	manualSourceKind,
+	sshConfigSourceKind,
+	termixSourceKind,
+	inventorySourceKind,
+	commandSourceKind,
+	terraformSourceKind,
+}
+
+// RegisterSourceKind adds a source kind, after the built-in ones
+func RegisterSourceKind(kind SourceKind) {
+	sourceKinds = append(sourceKinds, kind)
+}
+
+// SourceKinds returns the registered source kinds, in priority order
+func SourceKinds() []SourceKind {
+	return sourceKinds
+}
+
+// SplitSourceName splits a source name into its kind and instance name
+func SplitSourceName(source string) (kind, instance string) {
+	kind, instance, _ = strings.Cut(source, ":")
+	return kind, instance
+}
+
+// AllSources returns every configured source, enabled or not, in priority order
+func AllSources(config *models.Config) []HostSource {
+	var sources []HostSource
+	for _, kind := range sourceKinds {
+		sources = append(sources, kind.Sources(config)...)
+	}
+	return sources
+}
+
+// FindSource returns the source called name, or nil
+func FindSource(config *models.Config, name string) HostSource {
+	if name == "" {
+		name = SourceManual
+	}
+	for _, source := range AllSources(config) {
+		if source.Name() == name {
+			return source
+		}
+	}
+	return nil
+}
+
+// SourceResult is the outcome of loading hosts from a single source
+type SourceResult struct {
+	Source   string
+	Hosts    []models.Host
+	Warnings []string // Non-fatal problems (e.g. hosts served from cache)
+	Err      error    // Set when the source could not be loaded at all
+}
+
+// EnabledSources returns the names of the sources to load for config, in priority order
+func EnabledSources(config *models.Config) []string {
+	var sources []string
+	for _, source := range AllSources(config) {
+		if configurable, ok := source.(ConfigurableSource); ok && !configurable.Enabled() {
+			continue
+		}
+		sources = append(sources, source.Name())
+	}
+	return sources
+}
+
+// LoadSource loads hosts from the source called name. It does not modify
+// config, so it is safe to run several sources concurrently against the same config.
+func LoadSource(config *models.Config, name string) SourceResult {
+	source := FindSource(config, name)
+	if source == nil {
+		return SourceResult{Source: name, Err: fmt.Errorf("unknown source %q", name)}
+	}
+	return source.Load()
+}
+
+// LoadEnabledSources loads every enabled source of config, one after another
+func LoadEnabledSources(config *models.Config) []SourceResult {
+	var results []SourceResult
+	for _, source := range EnabledSources(config) {
+		results = append(results, LoadSource(config, source))
+	}
+	return results
+}
+
+// MergeSources applies the config's overlays to results and merges them
+func MergeSources(config *models.Config, results []SourceResult) MergeResult {
+	return Merge(ApplyOverlays(results, config.Overlays), config.Merge)
+}
+
+// IsAuthError reports whether err means a source needs the user to log in
+func IsAuthError(err error) bool {
+	var authErr *termix.AuthError
+	return errors.As(err, &authErr)
+}
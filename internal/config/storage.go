@@ -1,16 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
-	"sshbuddy/internal/ssh"
+	"sshbuddy/internal/atomicfile"
 	"sshbuddy/internal/termix"
 	"sshbuddy/pkg/models"
 )
 
+// GetDataPath returns the path of the config file: config.yaml, config.yml,
+// config.toml or config.json, whichever exists first. New configs are JSON.
 func GetDataPath() (string, error) {
 	// Use XDG_CONFIG_HOME if set, otherwise default to ~/.config
 	configDir := os.Getenv("XDG_CONFIG_HOME")
@@ -28,151 +32,142 @@ func GetDataPath() (string, error) {
 		return "", err
 	}
 	
+	for _, name := range configFileNames {
+		path := filepath.Join(sshbuddyDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
 	return filepath.Join(sshbuddyDir, "config.json"), nil
 }
 
-func LoadConfig() (*models.Config, error) {
+// ConfigFilePaths returns every path the config file may have, in the order
+// GetDataPath looks for them. Creating one that comes earlier switches to it.
+func ConfigFilePaths() ([]string, error) {
 	path, err := GetDataPath()
 	if err != nil {
-		logError("GetDataPath failed", err)
 		return nil, err
 	}
+	var paths []string
+	for _, name := range configFileNames {
+		paths = append(paths, filepath.Join(filepath.Dir(path), name))
+	}
+	return paths, nil
+}
 
-	var config models.Config
-	
-	// Load config from file
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Initialize with defaults
-		config = models.Config{
-			Hosts: []models.Host{},
-			Sources: models.SourcesConfig{
-				SSHBuddyEnabled:  true,
-				SSHConfigEnabled: true,
-				TermixEnabled:    false,
-			},
-			Termix: models.TermixConfig{
-				Enabled: false,
-			},
-			SSH: models.SSHConfig{
-				Enabled: true,
-			},
-		}
-	} else {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			logError("ReadFile failed", err)
-			return nil, err
-		}
-
-		if err := json.Unmarshal(data, &config); err != nil {
-			logError("Unmarshal config failed", err)
-			return nil, err
-		}
+// readConfigData reads the config file at path and returns its contents as JSON
+func readConfigData(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Mark manual hosts
-	for i := range config.Hosts {
-		if config.Hosts[i].Source == "" {
-			config.Hosts[i].Source = "manual"
-		}
+	converted, err := toJSON(data, ConfigFormat(path))
+	if err != nil {
+		return nil, configFileError(path, data, err)
 	}
-	
-	// Track all aliases to avoid duplicates
-	existingAliases := make(map[string]bool)
-	
-	// Only add manual hosts if SSHBuddy source is enabled
-	if config.Sources.SSHBuddyEnabled {
-		for _, host := range config.Hosts {
-			existingAliases[host.Alias] = true
-		}
-	} else {
-		// Clear manual hosts if disabled
-		config.Hosts = []models.Host{}
+	// Catch JSON syntax errors here, where their position is still meaningful
+	var raw any
+	if err := json.Unmarshal(converted, &raw); err != nil {
+		return nil, configFileError(path, data, err)
 	}
-	
-	// Load hosts from SSH config if enabled
-	if config.Sources.SSHConfigEnabled && config.SSH.Enabled {
-		sshHosts, err := ssh.LoadHostsFromSSHConfig()
-		if err == nil {
-			// Mark SSH config hosts
-			for i := range sshHosts {
-				sshHosts[i].Source = "ssh-config"
-			}
-			
-			// Add SSH config hosts that don't conflict
-			for _, sshHost := range sshHosts {
-				if !existingAliases[sshHost.Alias] {
-					config.Hosts = append(config.Hosts, sshHost)
-					existingAliases[sshHost.Alias] = true
-				}
-			}
-		}
+	return converted, nil
+}
+
+// encodeConfig converts JSON config contents to the format of the config
+// file at path, and reports whether they differ from what the file holds
+func encodeConfig(path string, data []byte) ([]byte, bool, error) {
+	previous, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, err
 	}
-	
-	// Load hosts from Termix API if enabled
-	if config.Sources.TermixEnabled && config.Termix.Enabled && config.Termix.BaseURL != "" {
-		logError("Termix config loaded", fmt.Errorf("baseUrl=%s", config.Termix.BaseURL))
-		
-		client := termix.NewClient(config.Termix.BaseURL, config.Termix.JWT, config.Termix.JWTExpiry)
-		
-		// Try to fetch hosts without credentials first (using cached token)
-		termixHosts, termixFetchErr := client.FetchHosts("", "")
-		
-		// If auth is required, return a special error that the TUI can handle
-		if termixFetchErr != nil {
-			if _, isAuthError := termixFetchErr.(*termix.AuthError); isAuthError {
-				// Return auth error to trigger credential prompt in TUI
-				return nil, termixFetchErr
-			}
-			
-			// Log other errors
-			logError("Termix FetchHosts failed", termixFetchErr)
-			
-			// Return error to show in UI with config file hint
-			configPath, _ := GetDataPath()
-			fullError := fmt.Errorf("%w\n\nCheck your Termix configuration at: %s", termixFetchErr, configPath)
-			logError("Returning error to UI", fullError)
-			return nil, fullError
-		}
-		
-		logError("Termix hosts fetched successfully", fmt.Errorf("count=%d", len(termixHosts)))
-		
-		// Add Termix hosts that don't conflict
-		for _, termixHost := range termixHosts {
-			if !existingAliases[termixHost.Alias] {
-				config.Hosts = append(config.Hosts, termixHost)
-				existingAliases[termixHost.Alias] = true
+	encoded, err := fromJSON(data, ConfigFormat(path), previous)
+	if err != nil {
+		return nil, false, err
+	}
+	return encoded, !bytes.Equal(previous, encoded), nil
+}
+
+// LoadConfig loads the config file and synchronously merges hosts from all
+// enabled sources. Source failures other than Termix auth errors are
+// reported through config.Warnings rather than failing the whole load.
+func LoadConfig() (*models.Config, error) {
+	config, err := LoadConfigRaw()
+	if err != nil {
+		slog.Error("Loading config failed", "err", err)
+		return nil, err
+	}
+
+	results := LoadEnabledSources(config)
+	for _, result := range results {
+		if result.Err != nil {
+			// Return auth error to trigger credential prompt in TUI
+			if IsAuthError(result.Err) {
+				return nil, result.Err
 			}
+			config.Warnings = append(config.Warnings, result.Err.Error())
 		}
-		
-		// Save the JWT token and expiry if they were updated
-		if client.GetJWT() != config.Termix.JWT || client.GetJWTExpiry() != config.Termix.JWTExpiry {
-			config.Termix.JWT = client.GetJWT()
-			config.Termix.JWTExpiry = client.GetJWTExpiry()
-			SaveConfig(&config)
-		}
+		config.Warnings = append(config.Warnings, result.Warnings...)
 	}
 
-	return &config, nil
+	config.Hosts = MergeSources(config, results).Hosts
+
+	return config, nil
 }
 
+// SaveConfig writes config to the config file. The write is atomic, and the
+// previous file is kept as a backup when its contents change.
 func SaveConfig(config *models.Config) error {
+	unlock, err := lockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return saveConfigLocked(config)
+}
+
+// UpdateConfig loads the config file, lets fn change it and saves it, holding
+// the config lock throughout so changes made by other sshbuddy processes in
+// the meantime aren't lost. Nothing is saved if fn returns an error.
+func UpdateConfig(fn func(config *models.Config) error) error {
+	unlock, err := lockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := loadConfigRaw(true)
+	if err != nil {
+		return err
+	}
+	if err := fn(config); err != nil {
+		return err
+	}
+	return saveConfigLocked(config)
+}
+
+// saveConfigLocked saves config; callers hold the config lock
+func saveConfigLocked(config *models.Config) error {
 	path, err := GetDataPath()
 	if err != nil {
 		return err
 	}
 
-	// Only save manual hosts (not SSH config or termix hosts)
-	saveConfig := &models.Config{
-		Theme:   config.Theme,
-		Sources: config.Sources,
-		Termix:  config.Termix,
-		SSH:     config.SSH,
-		Hosts:   []models.Host{},
+	// Save a copy, so the caller's hosts and tokens are left alone
+	saveConfig := *config
+	saveConfig.Version = CurrentConfigVersion
+	
+	// Copy the servers so blanking tokens below doesn't affect the caller
+	saveConfig.TermixServers = append([]models.TermixConfig(nil), config.TermixServers...)
+	
+	// Tokens go to the secret store, never into the JSON file
+	if err := storeSecrets(&saveConfig); err != nil {
+		return err
 	}
 	
+	// Only save manual hosts; other sources keep their hosts elsewhere
+	saveConfig.Hosts = []models.Host{}
 	for _, host := range config.Hosts {
-		if host.Source != "ssh-config" && host.Source != "termix" {
+		if host.Source == "" || host.Source == SourceManual {
 			saveConfig.Hosts = append(saveConfig.Hosts, host)
 		}
 	}
@@ -182,30 +177,64 @@ func SaveConfig(config *models.Config) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	encoded, changed, err := encodeConfig(path, data)
+	if err != nil {
+		return err
+	}
+	if changed {
+		// A failed backup shouldn't stop the save itself
+		if err := backupConfig(path, backupCount(config)); err != nil {
+			slog.Warn("Backing up config failed", "err", err)
+		}
+		if err := atomicfile.WriteFile(path, encoded, 0600); err != nil {
+			return err
+		}
+	} else if err := os.Chmod(path, 0600); err != nil {
+		// Unchanged, but tighten older 0644 configs
+		return err
+	}
+
+	// Keep the generated ssh_config in step with the hosts just saved
+	return UpdateManagedSSHConfig(config)
 }
 
 
 
-// logError logs errors to a debug file for troubleshooting
-func logError(context string, err error) {
-	logPath := "/tmp/sshbuddy-debug.log"
-	
-	logFile, fileErr := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if fileErr != nil {
-		return // Silently fail if we can't log
-	}
-	defer logFile.Close()
-	
-	timestamp := fmt.Sprintf("[%s]", os.Getenv("USER"))
-	logLine := fmt.Sprintf("%s %s: %v\n", timestamp, context, err)
-	logFile.WriteString(logLine)
+// LoadConfigRaw loads the config file without fetching external sources (SSH config, Termix).
+// Files from older versions are upgraded on disk first, keeping a backup.
+func LoadConfigRaw() (*models.Config, error) {
+	return loadConfigRaw(false)
 }
 
+// loadConfigRaw is LoadConfigRaw for callers that may already hold the config lock
+func loadConfigRaw(locked bool) (*models.Config, error) {
+	return loadConfigFile(func(path string, data []byte) ([]byte, error) {
+		return upgradeConfigFile(path, data, locked)
+	})
+}
 
+// ReadConfig loads the config file like LoadConfigRaw but never writes it:
+// files from older versions are upgraded in memory only. It also returns the
+// version of the file on disk, which is below CurrentConfigVersion while an
+// upgrade is pending. Used by sshbuddy doctor.
+func ReadConfig() (*models.Config, int, error) {
+	version := CurrentConfigVersion
+	config, err := loadConfigFile(func(path string, data []byte) ([]byte, error) {
+		var err error
+		if version, err = fileVersion(data); err != nil {
+			return nil, err
+		}
+		if version == CurrentConfigVersion {
+			return data, nil
+		}
+		return migrateConfig(data)
+	})
+	return config, version, err
+}
 
-// LoadConfigRaw loads the config file without fetching external sources (SSH config, Termix)
-func LoadConfigRaw() (*models.Config, error) {
+// loadConfigFile loads the config file, passing its contents through upgrade
+// first. A missing file gives the defaults.
+func loadConfigFile(upgrade func(path string, data []byte) ([]byte, error)) (*models.Config, error) {
 	path, err := GetDataPath()
 	if err != nil {
 		return nil, err
@@ -217,54 +246,68 @@ func LoadConfigRaw() (*models.Config, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		// Initialize with defaults
 		config = models.Config{
-			Hosts: []models.Host{},
+			Version: CurrentConfigVersion,
+			Hosts:   []models.Host{},
 			Sources: models.SourcesConfig{
 				SSHBuddyEnabled:  true,
 				SSHConfigEnabled: true,
-				TermixEnabled:    false,
-			},
-			Termix: models.TermixConfig{
-				Enabled: false,
 			},
 			SSH: models.SSHConfig{
 				Enabled: true,
 			},
 		}
 	} else {
-		data, err := os.ReadFile(path)
+		data, err := readConfigData(path)
 		if err != nil {
 			return nil, err
 		}
 
-		if err := json.Unmarshal(data, &config); err != nil {
+		data, err = upgrade(path, data)
+		if err != nil {
 			return nil, err
 		}
+
+		if err := json.Unmarshal(data, &config); err != nil {
+			// Positions in the converted JSON mean nothing in YAML or TOML files
+			if ConfigFormat(path) != FormatJSON {
+				return nil, &FileError{Path: path, Err: err}
+			}
+			return nil, configFileError(path, data, err)
+		}
+	}
+	
+	// Mark manual hosts
+	for i := range config.Hosts {
+		if config.Hosts[i].Source == "" {
+			config.Hosts[i].Source = SourceManual
+		}
 	}
 	
+	loadSecrets(&config)
+	
 	return &config, nil
 }
 
-// AuthenticateTermix authenticates with Termix using provided credentials and updates the config
-func AuthenticateTermix(username, password string) error {
+// AuthenticateTermix authenticates with the Termix server called name using
+// the provided credentials and stores the new token
+func AuthenticateTermix(name, username, password string) error {
 	// Load config without fetching Termix hosts to avoid circular dependency
 	config, err := LoadConfigRaw()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 	
-	if !config.Termix.Enabled || config.Termix.BaseURL == "" {
-		return fmt.Errorf("termix is not enabled or baseUrl is not configured")
+	server := FindTermixServer(config, name)
+	if server == nil || !server.Enabled || server.BaseURL == "" {
+		return fmt.Errorf("termix server %q is not enabled or baseUrl is not configured", name)
 	}
 	
-	client := termix.NewClient(config.Termix.BaseURL, "", 0)
+	client := termix.NewClient(server.BaseURL, "", 0)
 	jwt, expiry, err := client.Authenticate(username, password)
 	if err != nil {
 		return err
 	}
 	
-	// Update config with new token and expiry
-	config.Termix.JWT = jwt
-	config.Termix.JWTExpiry = expiry
-	
-	return SaveConfig(config)
+	// Store the new token against the current file, which may have changed meanwhile
+	return saveTermixToken(name, jwt, expiry)
 }
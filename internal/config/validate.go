@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+
+	"sshbuddy/pkg/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validate checks config like config.Validate, and points host problems at
+// the line the host starts on in the config file. config should hold the
+// file's hosts in file order, as LoadConfigRaw returns them.
+func Validate(config *models.Config) []models.ValidationError {
+	errs := config.Validate()
+	if warning := secretStoreWarning(config); warning != nil {
+		errs = append(errs, *warning)
+	}
+
+	path, err := GetDataPath()
+	if err != nil {
+		return errs
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errs
+	}
+	positions := hostPositions(data, ConfigFormat(path))
+	for i := range errs {
+		if index := errs[i].Index; index >= 0 && index < len(positions) {
+			errs[i].Line = positions[index].line
+			errs[i].Column = positions[index].column
+		}
+	}
+	return errs
+}
+
+// position is a 1-based line and column in a file; a column of 0 is unknown
+type position struct {
+	line, column int
+}
+
+// hostPositions returns where each entry of "hosts" starts in config file
+// contents, or nil if that can't be worked out
+func hostPositions(data []byte, format string) []position {
+	switch format {
+	case FormatYAML:
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+			return nil
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value != "hosts" {
+				continue
+			}
+			var positions []position
+			for _, host := range root.Content[i+1].Content {
+				positions = append(positions, position{host.Line, host.Column})
+			}
+			return positions
+		}
+		return nil
+	case FormatTOML:
+		// Only hosts written as [[hosts]] tables can be found
+		var positions []position
+		for i, line := range bytes.Split(data, []byte("\n")) {
+			if tomlHostsTable.Match(line) {
+				positions = append(positions, position{line: i + 1})
+			}
+		}
+		return positions
+	default:
+		return jsonHostPositions(data)
+	}
+}
+
+// tomlHostsTable matches the header of an entry of the hosts array
+var tomlHostsTable = regexp.MustCompile(`^\s*\[\[\s*hosts\s*\]\]`)
+
+// jsonHostPositions walks the top-level object of JSON data to the "hosts"
+// array and records where each of its entries starts
+func jsonHostPositions(data []byte) []position {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "hosts" {
+			var skip json.RawMessage
+			if dec.Decode(&skip) != nil {
+				return nil
+			}
+			continue
+		}
+
+		if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+			return nil
+		}
+		var positions []position
+		for dec.More() {
+			// The offset is just past the previous token; skip to the entry itself
+			offset := dec.InputOffset()
+			for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[offset]) >= 0 {
+				offset++
+			}
+			line, column := offsetPosition(data, offset)
+			positions = append(positions, position{line, column})
+
+			var skip json.RawMessage
+			if dec.Decode(&skip) != nil {
+				return positions
+			}
+		}
+		return positions
+	}
+	return nil
+}
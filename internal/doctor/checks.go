@@ -0,0 +1,295 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/internal/logging"
+	"sshbuddy/internal/ssh"
+	"sshbuddy/pkg/models"
+)
+
+// termixTimeout bounds each Termix reachability check
+const termixTimeout = 5 * time.Second
+
+// checkConfig checks the config file and returns it, or nil if it can't be loaded
+func checkConfig() (Section, *models.Config) {
+	s := section{Section{Title: "Config"}}
+
+	path, err := config.GetDataPath()
+	if err != nil {
+		s.fail("Config file", "can't determine its location: %v", err)
+		return s.Section, nil
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		s.pass("Config file", "%s not created yet; defaults are in use", displayPath(path))
+	case err != nil:
+		s.fail("Config file", "%s: %v", displayPath(path), err)
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0:
+		s.warn("Config file", "%s is accessible by other users (%04o); run chmod 600 %s", displayPath(path), info.Mode().Perm(), displayPath(path))
+	default:
+		s.pass("Config file", "%s (%s)", displayPath(path), config.ConfigFormat(path))
+	}
+
+	// Only the first of several config files is read, which surprises people
+	if paths, err := config.ConfigFilePaths(); err == nil {
+		for _, other := range paths {
+			if other == path {
+				continue
+			}
+			if _, err := os.Stat(other); err == nil {
+				s.warn("Config file", "%s is ignored because %s takes precedence", filepath.Base(other), filepath.Base(path))
+			}
+		}
+	}
+
+	// Saves go through a temporary file in the same directory
+	if probe, err := os.CreateTemp(filepath.Dir(path), ".doctor-*"); err != nil {
+		s.fail("Config directory", "%s is not writable: %v", displayPath(filepath.Dir(path)), err)
+	} else {
+		probe.Close()
+		os.Remove(probe.Name())
+		s.pass("Config directory", "%s is writable", displayPath(filepath.Dir(path)))
+	}
+
+	// Loading normally would upgrade an old file on disk; doctor only reads
+	cfg, version, err := config.ReadConfig()
+	if err != nil {
+		s.fail("Parse", "%v", err)
+		return s.Section, nil
+	}
+	if version < config.CurrentConfigVersion {
+		s.warn("Parse", "migration pending: version %d will be upgraded to %d the next time sshbuddy starts, keeping a backup", version, config.CurrentConfigVersion)
+	} else {
+		s.pass("Parse", "version %d, %d manual hosts", cfg.Version, len(cfg.Hosts))
+	}
+
+	problems := config.Validate(cfg)
+	for _, problem := range problems {
+		if problem.IsWarning() {
+			s.warn("Validation", "%s", problem.Error())
+		} else {
+			s.fail("Validation", "%s", problem.Error())
+		}
+	}
+	if len(problems) == 0 {
+		s.pass("Validation", "no problems found")
+	}
+
+	if store, err := config.OpenSecretStore(cfg); err != nil {
+		s.fail("Secret store", "%v", err)
+	} else if _, err := store.Get("doctor.check"); err != nil {
+		s.fail("Secret store", "%s store can't be read: %v", config.SecretStoreInUse(cfg), err)
+	} else if config.SecretKeyFileInUse(cfg) {
+		// Only worth a warning once there are tokens to protect
+		report := s.pass
+		if len(cfg.TermixServers) > 0 {
+			report = s.warn
+		}
+		report("Secret store", "file; its key is in secrets.key next to it, so only file permissions protect it (set SSHBUDDY_PASSPHRASE to require a passphrase, or use the keyring)")
+	} else {
+		s.pass("Secret store", "%s", config.SecretStoreInUse(cfg))
+	}
+
+	if cfg.Backups < 0 {
+		s.warn("Backups", "turned off; set \"backups\" to keep earlier versions of the config")
+	} else if dir, err := config.BackupDir(); err != nil {
+		s.warn("Backups", "%v", err)
+	} else if backups, err := config.ListBackups(); err != nil {
+		s.warn("Backups", "%v", err)
+	} else {
+		s.pass("Backups", "%d in %s", len(backups), displayPath(dir))
+	}
+
+	checkLog(&s)
+
+	return s.Section, cfg
+}
+
+// legacyLogPath is where versions before the state directory log wrote to
+const legacyLogPath = "/tmp/sshbuddy-debug.log"
+
+// checkLog reports where the log is, and flags the world-readable log of
+// older versions
+func checkLog(s *section) {
+	if path, err := logging.Path(); err != nil {
+		s.warn("Log", "%v", err)
+	} else {
+		s.pass("Log", "%s", displayPath(path))
+	}
+	if _, err := os.Stat(legacyLogPath); err == nil {
+		s.warn("Log", "%s from an older version may hold host data; delete it", legacyLogPath)
+	}
+}
+
+// checkSources loads every enabled source and checks each Termix server
+func checkSources(cfg *models.Config, offline bool) Section {
+	s := section{Section{Title: "Sources"}}
+
+	sources := config.EnabledSources(cfg)
+	if len(sources) == 0 {
+		s.warn("Sources", "none are enabled, so the host list is empty")
+	}
+
+	for _, source := range sources {
+		kind, instance := config.SplitSourceName(source)
+		switch kind {
+		case config.SourceManual:
+			s.pass(source, "%d hosts", len(cfg.Hosts))
+		case config.SourceTermix:
+			checkTermixServer(&s, source, config.FindTermixServer(cfg, instance), offline)
+		default:
+			checkSource(&s, cfg, source)
+		}
+	}
+	return s.Section
+}
+
+// checkSource loads one source and reports its hosts, warnings or error
+func checkSource(s *section, cfg *models.Config, source string) {
+	detail := ""
+	if source == config.SourceSSHConfig {
+		path, err := ssh.ConfigFilePath(cfg.SSH.ConfigPath)
+		if err != nil {
+			s.fail(source, "%v", err)
+			return
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			s.warn(source, "%s does not exist", displayPath(path))
+			return
+		}
+		detail = " from " + displayPath(path)
+	}
+
+	result := config.LoadSource(cfg, source)
+	if result.Err != nil {
+		s.fail(source, "%v", result.Err)
+		return
+	}
+	for _, warning := range result.Warnings {
+		s.warn(source, "%s", warning)
+	}
+	s.pass(source, "%d hosts%s", len(result.Hosts), detail)
+}
+
+// checkTermixServer checks that a Termix server answers and that its token is current
+func checkTermixServer(s *section, source string, server *models.TermixConfig, offline bool) {
+	if server == nil {
+		s.fail(source, "server is not configured")
+		return
+	}
+
+	if offline {
+		s.pass(source, "reachability not checked (offline)")
+	} else if elapsed, err := reachable(server.BaseURL); err != nil {
+		s.fail(source, "%s is unreachable: %v", server.BaseURL, err)
+	} else {
+		s.pass(source, "%s answered in %s", server.BaseURL, elapsed.Round(time.Millisecond))
+	}
+
+	switch {
+	case server.JWT == "" || server.JWTExpiry == 0:
+		s.warn(source+" token", "not signed in; sshbuddy will ask for credentials")
+	case time.Now().Unix() >= server.JWTExpiry:
+		s.warn(source+" token", "expired %s; sshbuddy will ask for credentials", time.Unix(server.JWTExpiry, 0).Format(time.RFC3339))
+	default:
+		s.pass(source+" token", "valid until %s", time.Unix(server.JWTExpiry, 0).Format(time.RFC3339))
+	}
+}
+
+// reachable reports how long the server at baseURL took to answer. Any HTTP
+// response counts; authentication is checked separately.
+func reachable(baseURL string) (time.Duration, error) {
+	client := &http.Client{Timeout: termixTimeout}
+	start := time.Now()
+	resp, err := client.Get(baseURL)
+	if err != nil {
+		// Drop the "Get <url>:" prefix; the URL is already in the report
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, err
+	}
+	resp.Body.Close()
+	return time.Since(start), nil
+}
+
+// checkSSH checks the ssh client, the agent and the generated ssh_config
+func checkSSH(cfg *models.Config) Section {
+	s := section{Section{Title: "SSH"}}
+
+	if path, err := exec.LookPath("ssh"); err != nil {
+		s.fail("ssh", "not found in PATH; sshbuddy runs the system ssh to connect")
+	} else {
+		// ssh -V prints to stderr
+		output, _ := exec.Command(path, "-V").CombinedOutput()
+		s.pass("ssh", "%s (%s)", displayPath(path), strings.TrimSpace(string(output)))
+	}
+
+	checkAgent(&s)
+
+	if cfg != nil && cfg.ManagedConfig.Enabled {
+		path, err := config.ManagedSSHConfigPath()
+		if err == nil {
+			if _, statErr := os.Stat(path); statErr != nil {
+				err = statErr
+			}
+		}
+		if err != nil {
+			s.fail("Generated ssh_config", "%v", err)
+		} else {
+			s.pass("Generated ssh_config", "%s", displayPath(path))
+		}
+		for _, alias := range config.SkippedManagedHosts(cfg) {
+			s.warn("Generated ssh_config", "%q is left out: a value contains a line break or a double quote", alias)
+		}
+	}
+
+	return s.Section
+}
+
+// checkAgent checks that an ssh agent is running and holds keys
+func checkAgent(s *section) {
+	if runtime.GOOS != "windows" && os.Getenv("SSH_AUTH_SOCK") == "" {
+		s.warn("ssh agent", "SSH_AUTH_SOCK is not set; keys with passphrases will be asked for on every connection")
+		return
+	}
+
+	sshAdd, err := exec.LookPath("ssh-add")
+	if err != nil {
+		s.pass("ssh agent", "SSH_AUTH_SOCK is set (ssh-add not found, keys not listed)")
+		return
+	}
+	output, err := exec.Command(sshAdd, "-l").CombinedOutput()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		keys := strings.Count(strings.TrimSpace(string(output)), "\n") + 1
+		s.pass("ssh agent", "running with %d key(s)", keys)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		s.warn("ssh agent", "running but holds no keys; add them with ssh-add")
+	default:
+		s.warn("ssh agent", "can't connect: %s", firstLine(string(output), err))
+	}
+}
+
+// firstLine returns the first line of output, or err if there is none
+func firstLine(output string, err error) string {
+	if line := strings.TrimSpace(strings.SplitN(output, "\n", 2)[0]); line != "" {
+		return line
+	}
+	return fmt.Sprint(err)
+}
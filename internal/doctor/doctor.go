@@ -0,0 +1,144 @@
+// Package doctor checks the sshbuddy setup (config file, sources, Termix
+// servers, ssh and its agent) and reports what is wrong in plain text that
+// can be pasted into an issue.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	Pass Status = iota
+	Warn
+	Fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case Warn:
+		return "warn"
+	case Fail:
+		return "FAIL"
+	default:
+		return "pass"
+	}
+}
+
+// Check is the result of one check
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// Section groups the checks of one area, such as the config file
+type Section struct {
+	Title  string
+	Checks []Check
+}
+
+// Options control what Run checks
+type Options struct {
+	Version string // sshbuddy version, for the report header
+	Offline bool   // Skip checks that need the network
+}
+
+// Report is the result of Run
+type Report struct {
+	Version  string
+	Sections []Section
+}
+
+// Run performs every check. It doesn't stop at failures, so the report
+// shows as much as possible.
+func Run(opts Options) Report {
+	report := Report{Version: opts.Version}
+
+	configSection, cfg := checkConfig()
+	report.Sections = append(report.Sections, configSection)
+	if cfg != nil {
+		report.Sections = append(report.Sections, checkSources(cfg, opts.Offline))
+	}
+	report.Sections = append(report.Sections, checkSSH(cfg))
+	return report
+}
+
+// Count returns how many checks ended with status
+func (r Report) Count(status Status) int {
+	count := 0
+	for _, section := range r.Sections {
+		for _, check := range section.Checks {
+			if check.Status == status {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Write prints the report as plain text
+func (r Report) Write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sshbuddy doctor\n")
+	fmt.Fprintf(&b, "version %s, %s/%s, %s\n", r.Version, runtime.GOOS, runtime.GOARCH, runtime.Version())
+
+	for _, section := range r.Sections {
+		fmt.Fprintf(&b, "\n%s\n", section.Title)
+		for _, check := range section.Checks {
+			line := fmt.Sprintf("  [%s] %s", check.Status, check.Name)
+			if check.Detail != "" {
+				line += ": " + check.Detail
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%d passed, %d warnings, %d failed\n", r.Count(Pass), r.Count(Warn), r.Count(Fail))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// section builds a Section check by check
+type section struct {
+	Section
+}
+
+func (s *section) pass(name, detail string, args ...any) {
+	s.add(Pass, name, detail, args...)
+}
+
+func (s *section) warn(name, detail string, args ...any) {
+	s.add(Warn, name, detail, args...)
+}
+
+func (s *section) fail(name, detail string, args ...any) {
+	s.add(Fail, name, detail, args...)
+}
+
+func (s *section) add(status Status, name, detail string, args ...any) {
+	if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+	s.Checks = append(s.Checks, Check{Name: name, Status: status, Detail: detail})
+}
+
+// displayPath shortens paths in the home directory to ~/..., which reads
+// better and keeps user names out of pasted reports
+func displayPath(path string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(homeDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return "~/" + filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,113 @@
+// Package hooks runs the commands configured to run before connecting to a
+// host and after its SSH session ends, such as bringing up a VPN or logging
+// session times.
+package hooks
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/pkg/models"
+)
+
+// Stage is the point of a connection at which hooks run
+type Stage string
+
+const (
+	PreConnect     Stage = "pre-connect"
+	PostDisconnect Stage = "post-disconnect"
+)
+
+// Commands returns the hook commands for host at stage: the global one, then
+// those of the host's tags in tag order, then the one for its alias
+func Commands(cfg models.HooksConfig, host models.Host, stage Stage) []string {
+	var commands []string
+	add := func(hooks models.Hooks) {
+		command := hooks.PreConnect
+		if stage == PostDisconnect {
+			command = hooks.PostDisconnect
+		}
+		if strings.TrimSpace(command) != "" {
+			commands = append(commands, command)
+		}
+	}
+
+	add(cfg.Hooks)
+	for _, tag := range host.Tags {
+		if hooks, ok := cfg.Tags[tag]; ok {
+			add(hooks)
+		}
+	}
+	if hooks, ok := cfg.Hosts[host.Alias]; ok {
+		add(hooks)
+	}
+	return commands
+}
+
+// Result describes a finished SSH session to post-disconnect hooks
+type Result struct {
+	ExitCode int
+	Duration time.Duration
+}
+
+// Env returns the environment variables describing host to hooks at stage.
+// result is only used for post-disconnect hooks.
+func Env(host models.Host, stage Stage, result Result) []string {
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	source := host.Source
+	if source == "" {
+		source = config.SourceManual
+	}
+
+	env := []string{
+		"SSHBUDDY_HOOK=" + string(stage),
+		"SSHBUDDY_ALIAS=" + host.Alias,
+		"SSHBUDDY_HOSTNAME=" + host.Hostname,
+		"SSHBUDDY_USER=" + host.User,
+		"SSHBUDDY_PORT=" + port,
+		"SSHBUDDY_TAGS=" + strings.Join(host.Tags, ","),
+		"SSHBUDDY_SOURCE=" + source,
+		"SSHBUDDY_IDENTITY_FILE=" + host.IdentityFile,
+		"SSHBUDDY_PROXY_JUMP=" + host.ProxyJump,
+	}
+	if stage == PostDisconnect {
+		env = append(env,
+			"SSHBUDDY_EXIT_CODE="+strconv.Itoa(result.ExitCode),
+			"SSHBUDDY_DURATION="+strconv.Itoa(int(result.Duration.Seconds())))
+	}
+	return env
+}
+
+// Run runs commands one after another with the shell, attached to the
+// terminal so they can print and prompt. It stops at the first that fails.
+func Run(commands []string, env []string) error {
+	for _, command := range commands {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), env...)
+
+		slog.Debug("Running hook", "command", command)
+		if err := cmd.Run(); err != nil {
+			slog.Warn("Hook failed", "command", command, "err", err)
+			return fmt.Errorf("hook %q failed: %w", command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,459 @@
+package inventory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"sshbuddy/pkg/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Implicit Ansible groups, which are not turned into tags
+const (
+	groupAll       = "all"
+	groupUngrouped = "ungrouped"
+)
+
+// ansibleInventory is the parsed form shared by the INI and YAML formats
+type ansibleInventory struct {
+	groups     map[string]*ansibleGroup
+	groupOrder []string // In order of first appearance
+	hosts      []string // In order of first appearance
+	hostVars   map[string]map[string]string
+	warnings   []string // Entries that were skipped, and why
+}
+
+type ansibleGroup struct {
+	hosts    []string
+	vars     map[string]string
+	children []string
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		groups:   make(map[string]*ansibleGroup),
+		hostVars: make(map[string]map[string]string),
+	}
+}
+
+func (inv *ansibleInventory) group(name string) *ansibleGroup {
+	g, ok := inv.groups[name]
+	if !ok {
+		g = &ansibleGroup{vars: make(map[string]string)}
+		inv.groups[name] = g
+		inv.groupOrder = append(inv.groupOrder, name)
+	}
+	return g
+}
+
+// addHosts adds the hosts pattern expands to. A pattern that can't be
+// expanded is skipped with a warning, so one bad entry doesn't lose the
+// rest of the inventory.
+func (inv *ansibleInventory) addHosts(group, pattern string, vars map[string]string, line int) {
+	names, err := expandHostPattern(pattern)
+	if err != nil {
+		inv.warnings = append(inv.warnings, fmt.Sprintf("line %d: %v, skipped", line, err))
+		return
+	}
+	for _, name := range names {
+		inv.addHost(group, name, vars)
+	}
+}
+
+// addHost adds host to group, merging vars into the host's own vars
+func (inv *ansibleInventory) addHost(group, host string, vars map[string]string) {
+	if _, seen := inv.hostVars[host]; !seen {
+		inv.hosts = append(inv.hosts, host)
+		inv.hostVars[host] = make(map[string]string)
+	}
+	for k, v := range vars {
+		inv.hostVars[host][k] = v
+	}
+	g := inv.group(group)
+	for _, h := range g.hosts {
+		if h == host {
+			return
+		}
+	}
+	g.hosts = append(g.hosts, host)
+}
+
+// resolve turns the inventory into hosts. Every group a host belongs to,
+// directly or through children, becomes a tag. Variables closer to the host
+// win: host vars, then its own groups, then parent groups, then "all".
+func (inv *ansibleInventory) resolve() []models.Host {
+	parents := make(map[string][]string)
+	directGroups := make(map[string][]string)
+	for _, name := range inv.groupOrder {
+		g := inv.groups[name]
+		for _, child := range g.children {
+			parents[child] = append(parents[child], name)
+		}
+		for _, host := range g.hosts {
+			directGroups[host] = append(directGroups[host], name)
+		}
+	}
+
+	hosts := make([]models.Host, 0, len(inv.hosts))
+	for _, name := range inv.hosts {
+		// Walk up the group tree one level at a time
+		var levels [][]string
+		seen := make(map[string]bool)
+		level := uniqueGroups(directGroups[name])
+		for len(level) > 0 {
+			var next []string
+			var current []string
+			for _, group := range level {
+				if seen[group] {
+					continue
+				}
+				seen[group] = true
+				current = append(current, group)
+				next = append(next, parents[group]...)
+			}
+			if len(current) > 0 {
+				levels = append(levels, current)
+			}
+			level = uniqueGroups(next)
+		}
+
+		vars := make(map[string]string)
+		if all, ok := inv.groups[groupAll]; ok {
+			mergeVars(vars, all.vars)
+		}
+		var tags []string
+		for i := len(levels) - 1; i >= 0; i-- {
+			for _, group := range levels[i] {
+				mergeVars(vars, inv.groups[group].vars)
+			}
+		}
+		for _, level := range levels {
+			for _, group := range level {
+				if group != groupAll && group != groupUngrouped {
+					tags = append(tags, group)
+				}
+			}
+		}
+		mergeVars(vars, inv.hostVars[name])
+
+		hosts = append(hosts, ansibleHost(name, vars, tags))
+	}
+	return hosts
+}
+
+// uniqueGroups drops duplicate groups, keeping the first occurrence
+func uniqueGroups(groups []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, g := range groups {
+		if !seen[g] {
+			seen[g] = true
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func mergeVars(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// ansibleHost maps Ansible connection variables to a host
+func ansibleHost(name string, vars map[string]string, tags []string) models.Host {
+	host := models.Host{
+		Alias:    name,
+		Hostname: firstVar(vars, "ansible_host", "ansible_ssh_host"),
+		User:     firstVar(vars, "ansible_user", "ansible_ssh_user"),
+		Port:     firstVar(vars, "ansible_port", "ansible_ssh_port"),
+		Tags:     tags,
+	}
+	if host.Hostname == "" {
+		host.Hostname = name
+	}
+	host.IdentityFile = firstVar(vars, "ansible_ssh_private_key_file", "ansible_private_key_file")
+	return host
+}
+
+func firstVar(vars map[string]string, names ...string) string {
+	for _, name := range names {
+		if v := vars[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ParseAnsibleINI parses an Ansible inventory in INI format, including
+// [group:vars] and [group:children] sections and host ranges like web[01:10].
+// It also returns warnings for entries it skipped.
+func ParseAnsibleINI(r io.Reader) ([]models.Host, []string, error) {
+	inv := newAnsibleInventory()
+	group := groupUngrouped
+	section := "hosts"
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, nil, fmt.Errorf("line %d: unterminated section header", lineNum)
+			}
+			name := line[1 : len(line)-1]
+			group, section = name, "hosts"
+			if i := strings.LastIndex(name, ":"); i >= 0 {
+				group, section = name[:i], name[i+1:]
+			}
+			if section != "hosts" && section != "vars" && section != "children" {
+				return nil, nil, fmt.Errorf("line %d: unknown section type %q", lineNum, section)
+			}
+			inv.group(group)
+			continue
+		}
+
+		fields, err := splitINIFields(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		switch section {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, nil, fmt.Errorf("line %d: expected key=value", lineNum)
+			}
+			inv.group(group).vars[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+		case "children":
+			g := inv.group(group)
+			g.children = append(g.children, fields[0])
+			inv.group(fields[0])
+		default:
+			vars := make(map[string]string)
+			for _, field := range fields[1:] {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					return nil, nil, fmt.Errorf("line %d: expected key=value, got %q", lineNum, field)
+				}
+				vars[key] = value
+			}
+			inv.addHosts(group, fields[0], vars, lineNum)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return inv.resolve(), inv.warnings, nil
+}
+
+// splitINIFields splits a host line on whitespace, keeping quoted values together
+func splitINIFields(line string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	inField := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == '#' && !inField:
+			// Comment after the last field
+			return fields, nil
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty entry")
+	}
+	return fields, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// expandHostPattern expands ranges like web[01:03], db-[a:c] and, with a
+// stride, web[01:10:2]
+func expandHostPattern(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "[")
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.Index(pattern[start:], "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated range in %q", pattern)
+	}
+	end += start
+
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	from, to, ok := strings.Cut(pattern[start+1:end], ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid range in %q", pattern)
+	}
+	step := 1
+	if last, stride, ok := strings.Cut(to, ":"); ok {
+		var err error
+		if step, err = strconv.Atoi(stride); err != nil || step < 1 {
+			return nil, fmt.Errorf("invalid stride in %q", pattern)
+		}
+		to = last
+	}
+
+	// Expand the rest of the pattern too, for names with several ranges
+	rest, err := expandHostPattern(suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	add := func(part string) {
+		for _, r := range rest {
+			names = append(names, prefix+part+r)
+		}
+	}
+
+	if lo, err := strconv.Atoi(from); err == nil {
+		hi, err := strconv.Atoi(to)
+		if err != nil || hi < lo {
+			return nil, fmt.Errorf("invalid range in %q", pattern)
+		}
+		for i := lo; i <= hi; i += step {
+			// Leading zeros in the start set the width
+			add(fmt.Sprintf("%0*d", len(from), i))
+		}
+		return names, nil
+	}
+
+	if len(from) == 1 && len(to) == 1 && from[0] <= to[0] {
+		for c := int(from[0]); c <= int(to[0]); c += step {
+			add(string(rune(c)))
+		}
+		return names, nil
+	}
+	return nil, fmt.Errorf("invalid range in %q", pattern)
+}
+
+// ParseAnsibleYAML parses an Ansible inventory in YAML format. The document
+// is walked as a node tree so hosts and groups keep the order of the file.
+// It also returns warnings for entries it skipped.
+func ParseAnsibleYAML(data []byte) ([]models.Host, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	inv := newAnsibleInventory()
+	if len(doc.Content) == 0 {
+		return inv.resolve(), nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("line %d: expected a mapping of groups", root.Line)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if err := addYAMLGroup(inv, root.Content[i].Value, root.Content[i+1]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return inv.resolve(), inv.warnings, nil
+}
+
+// addYAMLGroup adds a group node with its hosts, vars and children
+func addYAMLGroup(inv *ansibleInventory, name string, node *yaml.Node) error {
+	g := inv.group(name)
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: group %q must be a mapping", node.Line, name)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "vars":
+			vars, err := yamlVars(value)
+			if err != nil {
+				return err
+			}
+			mergeVars(g.vars, vars)
+		case "hosts":
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				hostVars, err := yamlVars(value.Content[j+1])
+				if err != nil {
+					return err
+				}
+				inv.addHosts(name, value.Content[j].Value, hostVars, value.Content[j].Line)
+			}
+		case "children":
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				childName := value.Content[j].Value
+				g.children = append(g.children, childName)
+				if err := addYAMLGroup(inv, childName, value.Content[j+1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// yamlVars decodes a mapping of variables; nested values are kept as text
+func yamlVars(node *yaml.Node) (map[string]string, error) {
+	vars := make(map[string]string)
+	if node.Kind != yaml.MappingNode {
+		return vars, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value any
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Content[i+1].Line, err)
+		}
+		if value != nil {
+			vars[node.Content[i].Value] = fmt.Sprint(value)
+		}
+	}
+	return vars, nil
+}
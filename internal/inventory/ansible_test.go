@@ -0,0 +1,306 @@
+package inventory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sshbuddy/pkg/models"
+)
+
+// hostSummary is the part of a host the Ansible parsers fill in
+type hostSummary struct {
+	Alias, Hostname, User, Port, IdentityFile string
+	Tags                                      []string
+}
+
+func summarize(hosts []models.Host) []hostSummary {
+	summaries := make([]hostSummary, len(hosts))
+	for i, h := range hosts {
+		summaries[i] = hostSummary{h.Alias, h.Hostname, h.User, h.Port, h.IdentityFile, h.Tags}
+	}
+	return summaries
+}
+
+func TestParseAnsibleINI(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     []hostSummary
+		warnings int
+	}{
+		{
+			name: "ungrouped and grouped hosts",
+			input: `bastion ansible_host=203.0.113.1
+
+[web]
+web1 ansible_host=10.0.0.1 ansible_user=deploy ansible_port=2222
+web2 ansible_ssh_host=10.0.0.2 ansible_ssh_user=ops ansible_ssh_port=2200
+`,
+			want: []hostSummary{
+				{Alias: "bastion", Hostname: "203.0.113.1"},
+				{Alias: "web1", Hostname: "10.0.0.1", User: "deploy", Port: "2222", Tags: []string{"web"}},
+				{Alias: "web2", Hostname: "10.0.0.2", User: "ops", Port: "2200", Tags: []string{"web"}},
+			},
+		},
+		{
+			name: "group vars and children",
+			input: `[web]
+web1
+
+[db]
+db1 ansible_user=postgres
+
+[prod:children]
+web
+db
+
+[prod:vars]
+ansible_user=deploy
+ansible_ssh_private_key_file=~/.ssh/prod
+
+[all:vars]
+ansible_port=2222
+`,
+			want: []hostSummary{
+				{Alias: "web1", Hostname: "web1", User: "deploy", Port: "2222", IdentityFile: "~/.ssh/prod", Tags: []string{"web", "prod"}},
+				{Alias: "db1", Hostname: "db1", User: "postgres", Port: "2222", IdentityFile: "~/.ssh/prod", Tags: []string{"db", "prod"}},
+			},
+		},
+		{
+			name: "child group vars win over parents",
+			input: `[app]
+app1
+
+[app:vars]
+ansible_user=app
+
+[prod:children]
+app
+
+[prod:vars]
+ansible_user=deploy
+`,
+			want: []hostSummary{
+				{Alias: "app1", Hostname: "app1", User: "app", Tags: []string{"app", "prod"}},
+			},
+		},
+		{
+			name: "quoted values and comments",
+			input: `[web]
+web1 ansible_host="10.0.0.1" # primary
+; a comment
+# another
+`,
+			want: []hostSummary{
+				{Alias: "web1", Hostname: "10.0.0.1", Tags: []string{"web"}},
+			},
+		},
+		{
+			name: "numeric range keeps the width",
+			input: `[web]
+web[08:10].example.com ansible_user=deploy
+`,
+			want: []hostSummary{
+				{Alias: "web08.example.com", Hostname: "web08.example.com", User: "deploy", Tags: []string{"web"}},
+				{Alias: "web09.example.com", Hostname: "web09.example.com", User: "deploy", Tags: []string{"web"}},
+				{Alias: "web10.example.com", Hostname: "web10.example.com", User: "deploy", Tags: []string{"web"}},
+			},
+		},
+		{
+			name:  "alphabetic range",
+			input: "db-[a:c]\n",
+			want: []hostSummary{
+				{Alias: "db-a", Hostname: "db-a"},
+				{Alias: "db-b", Hostname: "db-b"},
+				{Alias: "db-c", Hostname: "db-c"},
+			},
+		},
+		{
+			name:  "range with a stride",
+			input: "web[01:06:2]\n",
+			want: []hostSummary{
+				{Alias: "web01", Hostname: "web01"},
+				{Alias: "web03", Hostname: "web03"},
+				{Alias: "web05", Hostname: "web05"},
+			},
+		},
+		{
+			name: "bad range is skipped with a warning",
+			input: `[web]
+web1
+web[1:x]
+web[1:4:0]
+web2
+`,
+			want: []hostSummary{
+				{Alias: "web1", Hostname: "web1", Tags: []string{"web"}},
+				{Alias: "web2", Hostname: "web2", Tags: []string{"web"}},
+			},
+			warnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, warnings, err := ParseAnsibleINI(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseAnsibleINI: %v", err)
+			}
+			if got := summarize(hosts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("got warnings %q, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseAnsibleINIErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[web\nweb1\n", "unterminated section header"},
+		{"[web:hosts2]\n", "unknown section type"},
+		{"[web:vars]\nansible_user\n", "expected key=value"},
+		{"web1 ansible_user\n", "expected key=value"},
+		{"web1 ansible_host=\"10.0.0.1\n", "unterminated quote"},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseAnsibleINI(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want an error containing %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestParseAnsibleYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     []hostSummary
+		warnings int
+	}{
+		{
+			name: "hosts, vars and children",
+			input: `all:
+  vars:
+    ansible_port: 2222
+  children:
+    prod:
+      vars:
+        ansible_user: deploy
+      children:
+        web:
+          hosts:
+            web1:
+              ansible_host: 10.0.0.1
+            web2:
+        db:
+          hosts:
+            db1:
+              ansible_user: postgres
+              ansible_port: 5022
+`,
+			want: []hostSummary{
+				{Alias: "web1", Hostname: "10.0.0.1", User: "deploy", Port: "2222", Tags: []string{"web", "prod"}},
+				{Alias: "web2", Hostname: "web2", User: "deploy", Port: "2222", Tags: []string{"web", "prod"}},
+				{Alias: "db1", Hostname: "db1", User: "postgres", Port: "5022", Tags: []string{"db", "prod"}},
+			},
+		},
+		{
+			name: "host in several groups",
+			input: `web:
+  hosts:
+    app1:
+monitored:
+  hosts:
+    app1:
+      ansible_user: ops
+`,
+			want: []hostSummary{
+				{Alias: "app1", Hostname: "app1", User: "ops", Tags: []string{"web", "monitored"}},
+			},
+		},
+		{
+			name: "ranges",
+			input: `web:
+  hosts:
+    web[1:2]:
+    web[10:30:10]:
+      ansible_user: deploy
+`,
+			want: []hostSummary{
+				{Alias: "web1", Hostname: "web1", Tags: []string{"web"}},
+				{Alias: "web2", Hostname: "web2", Tags: []string{"web"}},
+				{Alias: "web10", Hostname: "web10", User: "deploy", Tags: []string{"web"}},
+				{Alias: "web20", Hostname: "web20", User: "deploy", Tags: []string{"web"}},
+				{Alias: "web30", Hostname: "web30", User: "deploy", Tags: []string{"web"}},
+			},
+		},
+		{
+			name: "bad range is skipped with a warning",
+			input: `web:
+  hosts:
+    web[3:1]:
+    web1:
+`,
+			want: []hostSummary{
+				{Alias: "web1", Hostname: "web1", Tags: []string{"web"}},
+			},
+			warnings: 1,
+		},
+		{
+			name:  "empty document",
+			input: "",
+			want:  []hostSummary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, warnings, err := ParseAnsibleYAML([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("ParseAnsibleYAML: %v", err)
+			}
+			if got := summarize(hosts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("got warnings %q, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestExpandHostPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"web1", []string{"web1"}},
+		{"web[1:3]", []string{"web1", "web2", "web3"}},
+		{"web[01:03]", []string{"web01", "web02", "web03"}},
+		{"web[01:10:3]", []string{"web01", "web04", "web07", "web10"}},
+		{"db-[a:e:2]", []string{"db-a", "db-c", "db-e"}},
+		{"rack[1:2]-node[a:b]", []string{"rack1-nodea", "rack1-nodeb", "rack2-nodea", "rack2-nodeb"}},
+	}
+	for _, tt := range tests {
+		got, err := expandHostPattern(tt.pattern)
+		if err != nil {
+			t.Errorf("%s: %v", tt.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	for _, pattern := range []string{"web[1", "web[1]", "web[3:1]", "web[1:x]", "web[1:5:0]", "web[1:5:-1]", "web[1:5:x]", "web[aa:b]"} {
+		if got, err := expandHostPattern(pattern); err == nil {
+			t.Errorf("%s: got %q, want an error", pattern, got)
+		}
+	}
+}
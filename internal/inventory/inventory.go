@@ -0,0 +1,314 @@
+// Package inventory reads hosts from inventory files: Ansible INI and YAML
+// inventories, CSV files and plain JSON/YAML host lists.
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sshbuddy/pkg/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Inventory file formats
+const (
+	FormatAuto        = ""
+	FormatAnsibleINI  = "ansible-ini"
+	FormatAnsibleYAML = "ansible-yaml"
+	FormatCSV         = "csv"
+	FormatJSON        = "json"
+	FormatYAML        = "yaml"
+)
+
+// Formats lists the accepted format names, for settings hints and errors
+var Formats = []string{FormatAnsibleINI, FormatAnsibleYAML, FormatCSV, FormatJSON, FormatYAML}
+
+// Load reads the inventory at path. With FormatAuto the format is guessed
+// from the file extension and, for YAML, from the content. It also returns
+// warnings for entries that were skipped.
+func Load(path, format string) ([]models.Host, []string, error) {
+	path = ExpandPath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if format == FormatAuto {
+		format = DetectFormat(path, data)
+	}
+
+	var hosts []models.Host
+	var warnings []string
+	switch format {
+	case FormatAnsibleINI:
+		hosts, warnings, err = ParseAnsibleINI(bytes.NewReader(data))
+	case FormatAnsibleYAML:
+		hosts, warnings, err = ParseAnsibleYAML(data)
+	case FormatCSV:
+		hosts, err = ParseCSV(bytes.NewReader(data))
+	case FormatJSON:
+		hosts, err = ParseHostList(data, false)
+	case FormatYAML:
+		hosts, err = ParseHostList(data, true)
+	default:
+		return nil, nil, fmt.Errorf("unknown inventory format %q (valid: %s)", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	for i := range warnings {
+		warnings[i] = filepath.Base(path) + ": " + warnings[i]
+	}
+	return hosts, warnings, nil
+}
+
+// DetectFormat guesses the format of an inventory file
+func DetectFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	case ".yml", ".yaml":
+		if isHostListYAML(data) {
+			return FormatYAML
+		}
+		return FormatAnsibleYAML
+	default:
+		// Ansible's own default; inventories often have no extension
+		return FormatAnsibleINI
+	}
+}
+
+// isHostListYAML reports whether data is a host list rather than an Ansible
+// inventory: either a top-level sequence or a mapping whose "hosts" is one
+func isHostListYAML(data []byte) bool {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	switch doc := doc.(type) {
+	case []any:
+		return true
+	case map[string]any:
+		_, isList := doc["hosts"].([]any)
+		return isList
+	}
+	return false
+}
+
+// ExpandPath expands a leading ~/ to the user's home directory
+func ExpandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// listHost is one entry of a JSON/YAML host list. Field names match the
+// hosts in config.json; the snake_case names that config files used before
+// version 4 are accepted too, since existing inventories and commands use them.
+type listHost struct {
+	Alias         string     `json:"alias"`
+	Hostname      string     `json:"hostname"`
+	User          string     `json:"user"`
+	Port          flexString `json:"port"`
+	Tags          []string   `json:"tags"`
+	IdentityFile  string     `json:"identityFile"`
+	ProxyJump     string     `json:"proxyJump"`
+	LocalForwards []string   `json:"localForwards"`
+	Note          string     `json:"note"`
+
+	IdentityFileSnake  string   `json:"identity_file"`
+	ProxyJumpSnake     string   `json:"proxy_jump"`
+	LocalForwardsSnake []string `json:"local_forwards"`
+}
+
+// flexString accepts both strings and numbers, so "port: 22" works
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("expected a string or number, got %s", data)
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
+// ParseHostList parses a JSON or YAML host list: either a list of hosts or
+// an object with a "hosts" list
+func ParseHostList(data []byte, isYAML bool) ([]models.Host, error) {
+	if isYAML {
+		// Go through the generic form so both formats share the JSON field rules
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []listHost
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+	} else {
+		var wrapper struct {
+			Hosts []listHost `json:"hosts"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, err
+		}
+		entries = wrapper.Hosts
+	}
+
+	hosts := make([]models.Host, 0, len(entries))
+	for i, entry := range entries {
+		if entry.IdentityFile == "" {
+			entry.IdentityFile = entry.IdentityFileSnake
+		}
+		if entry.ProxyJump == "" {
+			entry.ProxyJump = entry.ProxyJumpSnake
+		}
+		if entry.LocalForwards == nil {
+			entry.LocalForwards = entry.LocalForwardsSnake
+		}
+		host := models.Host{
+			Alias:         entry.Alias,
+			Hostname:      entry.Hostname,
+			User:          entry.User,
+			Port:          string(entry.Port),
+			Tags:          entry.Tags,
+			IdentityFile:  entry.IdentityFile,
+			ProxyJump:     entry.ProxyJump,
+			LocalForwards: entry.LocalForwards,
+			Note:          entry.Note,
+		}
+		if host.Alias == "" {
+			host.Alias = host.Hostname
+		}
+		if host.Alias == "" {
+			return nil, fmt.Errorf("host #%d has neither alias nor hostname", i+1)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// csvColumns maps accepted CSV header names to host fields
+var csvColumns = map[string]string{
+	"alias":         "alias",
+	"name":          "alias",
+	"hostname":      "hostname",
+	"host":          "hostname",
+	"user":          "user",
+	"port":          "port",
+	"tags":          "tags",
+	"identity_file": "identity_file",
+	"proxy_jump":    "proxy_jump",
+	"note":          "note",
+}
+
+// ParseCSV parses a CSV file with a header row. Columns are matched by
+// name; tags are separated by semicolons.
+func ParseCSV(r io.Reader) ([]models.Host, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []models.Host{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = csvColumns[strings.ToLower(strings.TrimSpace(name))]
+	}
+
+	hosts := []models.Host{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var host models.Host
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch columns[i] {
+			case "alias":
+				host.Alias = value
+			case "hostname":
+				host.Hostname = value
+			case "user":
+				host.User = value
+			case "port":
+				host.Port = value
+			case "tags":
+				host.Tags = splitTags(value)
+			case "identity_file":
+				host.IdentityFile = value
+			case "proxy_jump":
+				host.ProxyJump = value
+			case "note":
+				host.Note = value
+			}
+		}
+
+		if host.Alias == "" {
+			host.Alias = host.Hostname
+		}
+		if host.Alias == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: host has neither alias nor hostname", line)
+		}
+		if host.Port != "" {
+			if _, err := strconv.Atoi(host.Port); err != nil {
+				line, _ := reader.FieldPos(0)
+				return nil, fmt.Errorf("line %d: invalid port %q", line, host.Port)
+			}
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,156 @@
+// Package logging sets up the sshbuddy log: a leveled log/slog text log in
+// the user's state directory, with tokens, passwords and keys redacted.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	// DebugEnv turns on debug logging like the --debug flag
+	DebugEnv = "SSHBUDDY_DEBUG"
+
+	// maxLogSize is the size past which the log is rotated at startup
+	maxLogSize = 1 << 20
+
+	// keepLogs is how many rotated logs are kept, as sshbuddy.log.1 and so on
+	keepLogs = 3
+
+	redacted = "[REDACTED]"
+)
+
+// Dir returns the directory holding the log: $XDG_STATE_HOME/sshbuddy, or
+// ~/.local/state/sshbuddy. On Windows it is sshbuddy in the local app data.
+func Dir() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" && runtime.GOOS == "windows" {
+		// The local app data directory, as os.UserCacheDir returns it on Windows
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = cacheDir
+	}
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "sshbuddy"), nil
+}
+
+// Path returns the path of the log file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sshbuddy.log"), nil
+}
+
+// DebugRequested reports whether debug logging was asked for through DebugEnv
+func DebugRequested() bool {
+	value := os.Getenv(DebugEnv)
+	return value != "" && value != "0" && !strings.EqualFold(value, "false")
+}
+
+// Init makes the default slog logger write to the log file, at debug level
+// if debug is set and at info level otherwise. The returned function closes
+// the file. If the file can't be opened, logging is discarded and the error
+// returned, so that log calls never end up on the terminal.
+func Init(debug bool) (func(), error) {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+
+	file, err := openLog()
+	if err != nil {
+		slog.SetDefault(slog.New(newHandler(io.Discard, level)))
+		return func() {}, err
+	}
+	slog.SetDefault(slog.New(newHandler(file, level)))
+	return func() { file.Close() }, nil
+}
+
+// openLog opens the log file for appending, rotating it first if it is too big
+func openLog() (*os.File, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogSize {
+		rotate(path)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// Logs from older versions may have been created world-readable
+	file.Chmod(0600)
+	return file, nil
+}
+
+// rotate shifts path to path.1, path.1 to path.2 and so on, dropping the oldest
+func rotate(path string) {
+	os.Remove(fmt.Sprintf("%s.%d", path, keepLogs))
+	for i := keepLogs - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	os.Rename(path, path+".1")
+}
+
+// newHandler returns a text handler that redacts secrets
+func newHandler(w io.Writer, level slog.Level) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	})
+}
+
+// sensitiveKey matches attribute names whose values are always secret
+var sensitiveKey = regexp.MustCompile(`(?i)(password|passphrase|secret|token|jwt|cookie|authorization|private_?key)`)
+
+// redactAttr hides the values of sensitive attributes and any secrets that
+// turn up inside messages, strings and errors
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKey.MatchString(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+// Secrets recognised inside free text
+var (
+	jwtPattern        = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	privateKeyPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?(-----END [A-Z ]*PRIVATE KEY-----|$)`)
+	assignmentPattern = regexp.MustCompile(`(?i)("?(?:password|passphrase|secret|token|jwt|key_password)"?\s*[:=]\s*)("[^"]*"|[^\s,;&}]+)`)
+)
+
+// Redact replaces JWTs, private keys and password or token assignments in s
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = privateKeyPattern.ReplaceAllString(s, redacted)
+	return assignmentPattern.ReplaceAllString(s, "${1}"+redacted)
+}
@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,27 +11,48 @@ import (
 
 // SSHConfigHost represents a host entry from SSH config
 type SSHConfigHost struct {
-	Host             string
-	HostName         string
-	User             string
-	Port             string
-	IdentityFile     string
-	ProxyJump        string
-	ForwardAgent     string
-	LocalForward     string
-	RemoteForward    string
-	DynamicForward   string
+	Host                string
+	HostName            string
+	User                string
+	Port                string
+	IdentityFile        string
+	ProxyJump           string
+	ForwardAgent        string
+	LocalForward        string
+	RemoteForward       string
+	DynamicForward      string
 	ServerAliveInterval string
 }
 
 // ParseSSHConfig reads and parses the SSH config file
 func ParseSSHConfig() ([]SSHConfigHost, error) {
+	return ParseSSHConfigFile("")
+}
+
+// ConfigFilePath returns the SSH config file that configPath refers to:
+// ~/.ssh/config if it is empty, with a leading ~/ expanded otherwise
+func ConfigFilePath(configPath string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	if configPath == "" {
+		return filepath.Join(homeDir, ".ssh", "config"), nil
 	}
+	if strings.HasPrefix(configPath, "~/") {
+		return filepath.Join(homeDir, configPath[2:]), nil
+	}
+	return configPath, nil
+}
 
-	configPath := filepath.Join(homeDir, ".ssh", "config")
+// ParseSSHConfigFile reads and parses the SSH config file at configPath,
+// or ~/.ssh/config if configPath is empty. A leading ~/ is expanded.
+func ParseSSHConfigFile(configPath string) ([]SSHConfigHost, error) {
+	configPath, err := ConfigFilePath(configPath)
+	if err != nil {
+		return nil, err
+	}
 	
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -43,10 +65,20 @@ func ParseSSHConfig() ([]SSHConfigHost, error) {
 	}
 	defer file.Close()
 
+	return ParseSSHConfigReader(file)
+}
+
+// ParseSSHConfigReader parses SSH config content from r
+func ParseSSHConfigReader(r io.Reader) ([]SSHConfigHost, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
 	var hosts []SSHConfigHost
 	var currentHost *SSHConfigHost
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		
@@ -178,7 +210,13 @@ func ConvertToHost(sshHost SSHConfigHost) models.Host {
 
 // LoadHostsFromSSHConfig loads all hosts from SSH config
 func LoadHostsFromSSHConfig() ([]models.Host, error) {
-	sshHosts, err := ParseSSHConfig()
+	return LoadHostsFromSSHConfigFile("")
+}
+
+// LoadHostsFromSSHConfigFile loads hosts from the SSH config file at configPath
+// (see ParseSSHConfigFile) and converts them to models.Host
+func LoadHostsFromSSHConfigFile(configPath string) ([]models.Host, error) {
+	sshHosts, err := ParseSSHConfigFile(configPath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,190 @@
+// Package target opens SSH sessions outside the terminal sshbuddy runs in:
+// in a new tmux window or pane, a new screen window or a new terminal
+// emulator window. The session there is "sshbuddy connect", so hooks,
+// stored passwords and Termix keys work as they do in the current terminal.
+package target
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"sshbuddy/pkg/models"
+)
+
+// Target is a place sessions can open in
+type Target struct {
+	Name  string // One of models.ConnectTargets
+	Label string
+}
+
+// targets lists every target in menu order
+var targets = []Target{
+	{models.TargetTerminal, "This terminal"},
+	{models.TargetTmuxWindow, "New tmux window"},
+	{models.TargetTmuxPane, "New tmux pane"},
+	{models.TargetScreen, "New screen window"},
+	{models.TargetEmulator, "New terminal window"},
+}
+
+// Available returns the targets usable right now: this terminal always,
+// tmux and screen when sshbuddy runs inside them, and the emulator when a
+// command is configured for it
+func Available(cfg models.ConnectConfig) []Target {
+	var available []Target
+	for _, t := range targets {
+		if usable(cfg, t.Name) {
+			available = append(available, t)
+		}
+	}
+	return available
+}
+
+// usable reports whether the target called name can be used right now
+func usable(cfg models.ConnectConfig, name string) bool {
+	switch name {
+	case models.TargetTerminal:
+		return true
+	case models.TargetTmuxWindow, models.TargetTmuxPane:
+		return os.Getenv("TMUX") != ""
+	case models.TargetScreen:
+		return os.Getenv("STY") != ""
+	case models.TargetEmulator:
+		return strings.TrimSpace(cfg.Emulator) != ""
+	}
+	return false
+}
+
+// Default returns the configured default target, or this terminal if it
+// isn't usable, e.g. tmux when sshbuddy was started outside tmux
+func Default(cfg models.ConnectConfig) string {
+	if cfg.Target != "" && usable(cfg, cfg.Target) {
+		return cfg.Target
+	}
+	return models.TargetTerminal
+}
+
+// Label returns the menu label of the target called name
+func Label(name string) string {
+	for _, t := range targets {
+		if t.Name == name {
+			return t.Label
+		}
+	}
+	return name
+}
+
+// Open starts a session with host in the target called name, which must not
+// be this terminal, and returns without waiting for the session to end
+func Open(cfg models.ConnectConfig, name string, host models.Host) error {
+	if !usable(cfg, name) {
+		switch name {
+		case models.TargetTmuxWindow, models.TargetTmuxPane:
+			return fmt.Errorf("%s: sshbuddy is not running inside tmux", name)
+		case models.TargetScreen:
+			return fmt.Errorf("%s: sshbuddy is not running inside screen", name)
+		case models.TargetEmulator:
+			return fmt.Errorf("%s: no emulator command is configured", name)
+		}
+		return fmt.Errorf("unknown target %q", name)
+	}
+
+	session, err := sessionArgs(host)
+	if err != nil {
+		return err
+	}
+	slog.Debug("Opening session", "target", name, "alias", host.Alias, "source", host.Source)
+
+	switch name {
+	case models.TargetTmuxWindow:
+		return run("tmux", "new-window", "-n", host.Alias, shellJoin(session))
+	case models.TargetTmuxPane:
+		return run("tmux", "split-window", shellJoin(session))
+	case models.TargetScreen:
+		return run("screen", append([]string{"-t", host.Alias}, session...)...)
+	case models.TargetEmulator:
+		return startEmulator(cfg.Emulator, session, host)
+	}
+	return fmt.Errorf("%s can't open new sessions", name)
+}
+
+// sessionArgs returns the command line that connects to host in the new
+// window. -hold keeps the window open when the connection fails, so the
+// error can be read.
+func sessionArgs(host models.Host) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("can't find the sshbuddy executable: %w", err)
+	}
+	args := []string{exe, "connect", "-hold", "-target", models.TargetTerminal}
+	if host.Source != "" {
+		args = append(args, "-source", host.Source)
+	}
+	return append(args, "--", host.Alias), nil
+}
+
+// run runs a tmux or screen command, which returns once the window is open
+func run(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// startEmulator fills in the emulator command template and starts it in the
+// background. {command} becomes the session command and {title} the alias,
+// both quoted for the shell.
+func startEmulator(template string, session []string, host models.Host) error {
+	command := strings.NewReplacer(
+		"{command}", shellJoin(session),
+		"{title}", shellQuote(host.Alias),
+	).Replace(template)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	// Keep the emulator's own output off the TUI
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, nil, nil
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting the emulator: %w", err)
+	}
+	// Some emulators stay in the foreground until their window closes;
+	// reap them in the background so they don't linger as zombies
+	go cmd.Wait()
+	return nil
+}
+
+// shellJoin quotes args for the shell and joins them with spaces
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// plainWord matches arguments the shell takes literally
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for the shell: POSIX single quotes, or double quotes
+// for cmd.exe on Windows
+func shellQuote(s string) string {
+	if plainWord.MatchString(s) {
+		return s
+	}
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
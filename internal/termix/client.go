@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"sshbuddy/pkg/models"
@@ -14,43 +14,58 @@ import (
 
 // TermixHost represents the API response structure from Termix
 type TermixHost struct {
-	ID                         int      `json:"id"`
-	UserID                     string   `json:"userId"`
-	Name                       string   `json:"name"`
-	IP                         string   `json:"ip"`
-	Port                       int      `json:"port"`
-	Username                   string   `json:"username"`
-	Folder                     string   `json:"folder"`
-	Tags                       []string `json:"tags"`
-	Pin                        bool     `json:"pin"`
-	AuthType                   string   `json:"authType"`
-	ForceKeyboardInteractive   bool     `json:"forceKeyboardInteractive"`
-	Password                   *string  `json:"password"`
-	Key                        *string  `json:"key"`
-	KeyPassword                *string  `json:"key_password"`
-	KeyType                    string   `json:"keyType"`
-	AutostartPassword          *string  `json:"autostartPassword"`
-	AutostartKey               *string  `json:"autostartKey"`
-	AutostartKeyPassword       *string  `json:"autostartKeyPassword"`
-	CredentialID               *int     `json:"credentialId"`
-	OverrideCredentialUsername *string  `json:"overrideCredentialUsername"`
-	EnableTerminal             bool     `json:"enableTerminal"`
-	EnableTunnel               bool     `json:"enableTunnel"`
-	TunnelConnections          []any    `json:"tunnelConnections"`
-	JumpHosts                  []any    `json:"jumpHosts"`
-	EnableFileManager          bool     `json:"enableFileManager"`
-	DefaultPath                string   `json:"defaultPath"`
-	QuickActions               []any    `json:"quickActions"`
-	CreatedAt                  string   `json:"createdAt"`
-	UpdatedAt                  string   `json:"updatedAt"`
+	ID                         int                      `json:"id"`
+	UserID                     string                   `json:"userId"`
+	Name                       string                   `json:"name"`
+	IP                         string                   `json:"ip"`
+	Port                       int                      `json:"port"`
+	Username                   string                   `json:"username"`
+	Folder                     string                   `json:"folder"`
+	Tags                       []string                 `json:"tags"`
+	Pin                        bool                     `json:"pin"`
+	AuthType                   string                   `json:"authType"`
+	ForceKeyboardInteractive   bool                     `json:"forceKeyboardInteractive"`
+	Password                   *string                  `json:"password"`
+	Key                        *string                  `json:"key"`
+	KeyPassword                *string                  `json:"key_password"`
+	KeyType                    string                   `json:"keyType"`
+	AutostartPassword          *string                  `json:"autostartPassword"`
+	AutostartKey               *string                  `json:"autostartKey"`
+	AutostartKeyPassword       *string                  `json:"autostartKeyPassword"`
+	CredentialID               *int                     `json:"credentialId"`
+	OverrideCredentialUsername flexBool                 `json:"overrideCredentialUsername"`
+	EnableTerminal             bool                     `json:"enableTerminal"`
+	EnableTunnel               bool                     `json:"enableTunnel"`
+	TunnelConnections          []TermixTunnelConnection `json:"tunnelConnections"`
+	JumpHosts                  []TermixJumpHost         `json:"jumpHosts"`
+	EnableFileManager          bool                     `json:"enableFileManager"`
+	DefaultPath                string                   `json:"defaultPath"`
+	QuickActions               []any                    `json:"quickActions"`
+	CreatedAt                  string                   `json:"createdAt"`
+	UpdatedAt                  string                   `json:"updatedAt"`
+}
+
+// TermixJumpHost references another Termix host to hop through
+type TermixJumpHost struct {
+	HostID int `json:"hostId"`
+}
+
+// TermixTunnelConnection represents a port forward configured on a Termix host
+type TermixTunnelConnection struct {
+	SourcePort    int    `json:"sourcePort"`
+	EndpointPort  int    `json:"endpointPort"`
+	EndpointHost  string `json:"endpointHost"` // Name of another Termix host
+	MaxRetries    int    `json:"maxRetries"`
+	RetryInterval int    `json:"retryInterval"`
+	AutoStart     bool   `json:"autoStart"`
 }
 
 // Config holds Termix API configuration
 type Config struct {
-	Enabled    bool   `json:"enabled"`
-	BaseURL    string `json:"baseUrl"`
-	JWT        string `json:"jwt,omitempty"`        // Cached JWT token
-	JWTExpiry  int64  `json:"jwtExpiry,omitempty"`  // JWT expiry timestamp (Unix time)
+	Enabled   bool   `json:"enabled"`
+	BaseURL   string `json:"baseUrl"`
+	JWT       string `json:"jwt,omitempty"`       // Cached JWT token
+	JWTExpiry int64  `json:"jwtExpiry,omitempty"` // JWT expiry timestamp (Unix time)
 }
 
 // Client handles communication with Termix API
@@ -76,7 +91,7 @@ func NewClient(baseURL, jwt string, jwtExpiry int64) *Client {
 // Authenticate logs in to Termix and returns the JWT token and expiry
 func (c *Client) Authenticate(username, password string) (string, int64, error) {
 	loginURL := c.baseURL + "/users/login"
-	logDebug("Termix Authenticate", fmt.Sprintf("URL: %s, Username: %s", loginURL, username))
+	slog.Debug("Termix authenticate", "url", loginURL, "user", username)
 	
 	loginData := map[string]string{
 		"username": username,
@@ -97,17 +112,16 @@ func (c *Client) Authenticate(username, password string) (string, int64, error)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		logDebug("Termix Auth Request Failed", err.Error())
+		slog.Debug("Termix auth request failed", "err", err)
 		return "", 0, fmt.Errorf("termix: connection failed (check baseUrl and network): %w", err)
 	}
 	defer resp.Body.Close()
 
-	logDebug("Termix Auth Response", fmt.Sprintf("Status: %d", resp.StatusCode))
+	slog.Debug("Termix auth response", "status", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		bodyPreview := string(body)
-		logDebug("Termix Auth Failed Body", bodyPreview)
 		if len(bodyPreview) > 200 {
 			bodyPreview = bodyPreview[:200] + "..."
 		}
@@ -158,7 +172,7 @@ func (e *AuthError) Error() string {
 
 // FetchHosts retrieves hosts from the Termix API
 func (c *Client) FetchHosts(username, password string) ([]models.Host, error) {
-	logDebug("Termix FetchHosts", fmt.Sprintf("Starting, JWT present: %v, expired: %v", c.jwt != "", c.IsTokenExpired()))
+	slog.Debug("Termix fetch hosts", "signedIn", c.jwt != "", "expired", c.IsTokenExpired())
 	
 	// Check if token is expired or missing
 	if c.IsTokenExpired() {
@@ -168,16 +182,16 @@ func (c *Client) FetchHosts(username, password string) ([]models.Host, error) {
 		
 		jwt, expiry, err := c.Authenticate(username, password)
 		if err != nil {
-			logDebug("Termix FetchHosts Auth Failed", err.Error())
+			slog.Debug("Termix fetch hosts: authentication failed", "err", err)
 			return nil, err
 		}
 		c.jwt = jwt
 		c.jwtExpiry = expiry
-		logDebug("Termix FetchHosts Auth Success", "JWT obtained")
+		slog.Debug("Termix fetch hosts: authenticated")
 	}
 
 	hostsURL := c.baseURL + "/ssh/db/host"
-	logDebug("Termix FetchHosts URL", hostsURL)
+	slog.Debug("Termix fetch hosts request", "url", hostsURL)
 	req, err := http.NewRequest("GET", hostsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("termix: failed to create request: %w", err)
@@ -230,49 +244,103 @@ func (c *Client) FetchHosts(username, password string) ([]models.Host, error) {
 		defer resp.Body.Close()
 	}
 
-	logDebug("Termix FetchHosts Response", fmt.Sprintf("Status: %d", resp.StatusCode))
+	slog.Debug("Termix fetch hosts response", "status", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		bodyPreview := string(body)
-		logDebug("Termix FetchHosts Error Body", bodyPreview)
 		if len(bodyPreview) > 200 {
 			bodyPreview = bodyPreview[:200] + "..."
 		}
 		return nil, fmt.Errorf("termix: API returned status %d: %s", resp.StatusCode, bodyPreview)
 	}
 
-	// Read the body first for logging
+	// Read the whole body first, so a decode error can show part of it
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logDebug("Termix FetchHosts Read Body Failed", err.Error())
 		return nil, fmt.Errorf("termix: failed to read response body: %w", err)
 	}
 	
-	logDebug("Termix FetchHosts Response Body", string(bodyBytes)[:min(len(bodyBytes), 500)])
+	slog.Debug("Termix fetch hosts body", "bytes", len(bodyBytes))
 
 	var termixHosts []TermixHost
 	if err := json.Unmarshal(bodyBytes, &termixHosts); err != nil {
 		bodyPreview := string(bodyBytes)
-		logDebug("Termix FetchHosts JSON Decode Failed", fmt.Sprintf("Error: %v, Body: %s", err, bodyPreview))
+		slog.Debug("Termix fetch hosts: invalid JSON", "err", err)
 		if len(bodyPreview) > 100 {
 			bodyPreview = bodyPreview[:100] + "..."
 		}
 		return nil, fmt.Errorf("termix API returned invalid JSON (check baseUrl in termix.json): %s", bodyPreview)
 	}
 	
-	logDebug("Termix FetchHosts Success", fmt.Sprintf("Decoded %d hosts", len(termixHosts)))
+	slog.Debug("Termix fetch hosts: decoded", "count", len(termixHosts))
+
+	// Index hosts so jump hosts and tunnel endpoints can be resolved
+	byID := make(map[int]TermixHost, len(termixHosts))
+	byName := make(map[string]TermixHost, len(termixHosts))
+	for _, th := range termixHosts {
+		byID[th.ID] = th
+		byName[th.Name] = th
+	}
 
 	// Convert Termix hosts to sshbuddy hosts
 	hosts := make([]models.Host, 0, len(termixHosts))
 	for _, th := range termixHosts {
 		host := convertTermixHost(th)
+		c.resolveCredential(th, &host)
+		host.ProxyJump = resolveJumpHosts(th, byID)
+		host.LocalForwards = resolveTunnels(th, byName)
 		hosts = append(hosts, host)
 	}
 
 	return hosts, nil
 }
 
+// resolveJumpHosts builds a ProxyJump chain from the host's jump host IDs
+func resolveJumpHosts(th TermixHost, byID map[int]TermixHost) string {
+	var hops []string
+	for _, jump := range th.JumpHosts {
+		jh, ok := byID[jump.HostID]
+		if !ok {
+			slog.Warn("Termix jump host unresolved", "host", th.ID, "jumpHost", jump.HostID)
+			continue
+		}
+		hop := jh.IP
+		if jh.Username != "" {
+			hop = jh.Username + "@" + hop
+		}
+		if jh.Port != 0 && jh.Port != 22 {
+			hop = hop + ":" + strconv.Itoa(jh.Port)
+		}
+		hops = append(hops, hop)
+	}
+	return strings.Join(hops, ",")
+}
+
+// resolveTunnels translates tunnel connections into local forwards
+// ("sourcePort:endpointAddress:endpointPort") through this host
+func resolveTunnels(th TermixHost, byName map[string]TermixHost) []string {
+	if !th.EnableTunnel {
+		return nil
+	}
+
+	var forwards []string
+	for _, tunnel := range th.TunnelConnections {
+		if tunnel.SourcePort == 0 || tunnel.EndpointPort == 0 {
+			continue
+		}
+		endpoint := tunnel.EndpointHost
+		if eh, ok := byName[tunnel.EndpointHost]; ok {
+			endpoint = eh.IP
+		}
+		if endpoint == "" {
+			endpoint = "localhost"
+		}
+		forwards = append(forwards, fmt.Sprintf("%d:%s:%d", tunnel.SourcePort, endpoint, tunnel.EndpointPort))
+	}
+	return forwards
+}
+
 // convertTermixHost converts a Termix host to sshbuddy host format
 func convertTermixHost(th TermixHost) models.Host {
 	host := models.Host{
@@ -282,13 +350,20 @@ func convertTermixHost(th TermixHost) models.Host {
 		Port:     strconv.Itoa(th.Port),
 		Tags:     th.Tags,
 		Source:   "termix",
+		SourceID: strconv.Itoa(th.ID), // Stable across renames
 	}
 
-	// Handle SSH key if present
-	if th.Key != nil && *th.Key != "" {
-		// Note: Termix stores the key content, but sshbuddy expects a file path
-		// We'll need to handle this appropriately - for now, we'll skip it
-		// In a production scenario, you might want to write the key to a temp file
+	// Password auth is answered by the askpass helper at connect time
+	if th.AuthType == "password" && th.Password != nil {
+		host.Password = *th.Password
+	}
+
+	// Key content is written to a private session file at connect time
+	if th.AuthType == "key" && th.Key != nil && *th.Key != "" {
+		host.Key = *th.Key
+		if th.KeyPassword != nil {
+			host.KeyPassphrase = *th.KeyPassword
+		}
 	}
 
 	return host
@@ -304,26 +379,3 @@ func (c *Client) GetJWT() string {
 func (c *Client) GetJWTExpiry() int64 {
 	return c.jwtExpiry
 }
-
-// logDebug logs debug information to a file for troubleshooting
-func logDebug(context string, message string) {
-	logPath := "/tmp/sshbuddy-debug.log"
-	
-	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return // Silently fail if we can't log
-	}
-	defer logFile.Close()
-	
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logLine := fmt.Sprintf("[%s] %s: %s\n", timestamp, context, message)
-	logFile.WriteString(logLine)
-}
-
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
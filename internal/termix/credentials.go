@@ -0,0 +1,160 @@
+package termix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sshbuddy/pkg/models"
+)
+
+// TermixCredential represents a shared credential from the Termix credential store
+type TermixCredential struct {
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Username       string  `json:"username"`
+	AuthType       string  `json:"authType"`
+	Password       *string `json:"password"`
+	Key            *string `json:"key"`
+	KeyPassword    *string `json:"keyPassword"`
+	KeyPasswordAlt *string `json:"key_password"` // Older servers use snake_case
+	KeyType        string  `json:"keyType"`
+}
+
+// keyPassword returns the key passphrase regardless of field naming
+func (tc *TermixCredential) keyPassword() string {
+	if tc.KeyPassword != nil {
+		return *tc.KeyPassword
+	}
+	if tc.KeyPasswordAlt != nil {
+		return *tc.KeyPasswordAlt
+	}
+	return ""
+}
+
+// flexBool decodes booleans that Termix may send as true/false, 0/1 or strings
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	switch strings.ToLower(raw) {
+	case "", "null", "false", "0":
+		*b = false
+	case "true", "1":
+		*b = true
+	default:
+		return fmt.Errorf("termix: invalid boolean %s", string(data))
+	}
+	return nil
+}
+
+// credentialCache keeps fetched credentials for the lifetime of the process,
+// keyed by server base URL and credential ID
+var credentialCache = struct {
+	sync.Mutex
+	entries map[string]*TermixCredential
+}{entries: make(map[string]*TermixCredential)}
+
+// resetCredentialCache forgets every cached credential
+func resetCredentialCache() {
+	credentialCache.Lock()
+	credentialCache.entries = make(map[string]*TermixCredential)
+	credentialCache.Unlock()
+}
+
+// FetchCredential retrieves a single credential, using the session cache when possible
+func (c *Client) FetchCredential(id int) (*TermixCredential, error) {
+	cacheKey := c.baseURL + "#" + strconv.Itoa(id)
+
+	credentialCache.Lock()
+	cached, ok := credentialCache.entries[cacheKey]
+	credentialCache.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	credentialURL := fmt.Sprintf("%s/credentials/%d", c.baseURL, id)
+	slog.Debug("Termix fetch credential", "url", credentialURL)
+
+	req, err := http.NewRequest("GET", credentialURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("termix: failed to create credential request: %w", err)
+	}
+	req.AddCookie(&http.Cookie{Name: "jwt", Value: c.jwt})
+	req.AddCookie(&http.Cookie{Name: "i18nextLng", Value: "en"})
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("termix: failed to fetch credential %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	slog.Debug("Termix fetch credential response", "status", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, &AuthError{Message: "termix: authentication required - token invalid"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("termix: credential %d returned status %d", id, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("termix: failed to read credential %d: %w", id, err)
+	}
+
+	var credential TermixCredential
+	if err := json.Unmarshal(body, &credential); err != nil {
+		return nil, fmt.Errorf("termix: credential %d returned invalid JSON: %w", id, err)
+	}
+
+	credentialCache.Lock()
+	credentialCache.entries[cacheKey] = &credential
+	credentialCache.Unlock()
+
+	return &credential, nil
+}
+
+// resolveCredential applies a host's shared credential to the converted host.
+// Hosts without a credential keep whatever convertTermixHost derived.
+func (c *Client) resolveCredential(th TermixHost, host *models.Host) {
+	if th.CredentialID == nil {
+		return
+	}
+
+	credential, err := c.FetchCredential(*th.CredentialID)
+	if err != nil {
+		slog.Warn("Resolving Termix credential failed", "host", th.ID, "err", err)
+		return
+	}
+
+	applyCredential(host, credential, bool(th.OverrideCredentialUsername))
+}
+
+// applyCredential sets the effective username and secrets from a credential.
+// When overrideUsername is set the host keeps its own username.
+func applyCredential(host *models.Host, credential *TermixCredential, overrideUsername bool) {
+	if !overrideUsername && credential.Username != "" {
+		host.User = credential.Username
+	}
+
+	switch credential.AuthType {
+	case "password":
+		if credential.Password != nil {
+			host.Password = *credential.Password
+		}
+		host.Key = ""
+		host.KeyPassphrase = ""
+	case "key":
+		if credential.Key != nil {
+			host.Key = *credential.Key
+			host.KeyPassphrase = credential.keyPassword()
+		}
+		host.Password = ""
+	}
+}
@@ -0,0 +1,208 @@
+package termix
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"sshbuddy/pkg/models"
+)
+
+// fakeCredentialServer serves credentials by ID from a map of JSON bodies and
+// counts the requests it receives. Requests without the expected JWT get 401.
+func fakeCredentialServer(t *testing.T, credentials map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cookie, err := r.Cookie("jwt")
+		if err != nil || cookie.Value != "good-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := credentials[strings.TrimPrefix(r.URL.Path, "/credentials/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(resetCredentialCache)
+	resetCredentialCache()
+	return server, &requests
+}
+
+func TestFetchCredentialPassword(t *testing.T) {
+	server, _ := fakeCredentialServer(t, map[string]string{
+		"1": `{"id":1,"name":"ops","username":"deploy","authType":"password","password":"hunter2"}`,
+	})
+	client := NewClient(server.URL, "good-token", 0)
+
+	credential, err := client.FetchCredential(1)
+	if err != nil {
+		t.Fatalf("FetchCredential: %v", err)
+	}
+	host := models.Host{User: "root", Key: "old key", KeyPassphrase: "old passphrase"}
+	applyCredential(&host, credential, false)
+
+	if host.User != "deploy" || host.Password != "hunter2" {
+		t.Errorf("got user %q password %q, want deploy/hunter2", host.User, host.Password)
+	}
+	if host.Key != "" || host.KeyPassphrase != "" {
+		t.Errorf("password credential kept key %q passphrase %q", host.Key, host.KeyPassphrase)
+	}
+}
+
+func TestFetchCredentialKey(t *testing.T) {
+	server, _ := fakeCredentialServer(t, map[string]string{
+		"2": `{"id":2,"username":"deploy","authType":"key","key":"PRIVATE KEY","keyPassword":"secret"}`,
+		"3": `{"id":3,"username":"deploy","authType":"key","key":"PRIVATE KEY","key_password":"legacy"}`,
+	})
+	client := NewClient(server.URL, "good-token", 0)
+
+	tests := []struct {
+		id         int
+		passphrase string
+	}{
+		{2, "secret"},
+		{3, "legacy"},
+	}
+	for _, tt := range tests {
+		credential, err := client.FetchCredential(tt.id)
+		if err != nil {
+			t.Fatalf("FetchCredential(%d): %v", tt.id, err)
+		}
+		host := models.Host{User: "root", Password: "old password"}
+		applyCredential(&host, credential, true)
+
+		if host.User != "root" {
+			t.Errorf("credential %d: overridden username changed to %q", tt.id, host.User)
+		}
+		if host.Key != "PRIVATE KEY" || host.KeyPassphrase != tt.passphrase {
+			t.Errorf("credential %d: got key %q passphrase %q", tt.id, host.Key, host.KeyPassphrase)
+		}
+		if host.Password != "" {
+			t.Errorf("credential %d: key credential kept password %q", tt.id, host.Password)
+		}
+	}
+}
+
+func TestFetchCredentialErrors(t *testing.T) {
+	server, _ := fakeCredentialServer(t, map[string]string{})
+
+	_, err := NewClient(server.URL, "expired-token", 0).FetchCredential(1)
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Errorf("401: got %v, want *AuthError", err)
+	}
+
+	_, err = NewClient(server.URL, "good-token", 0).FetchCredential(404)
+	if err == nil || errors.As(err, &authErr) {
+		t.Errorf("404: got %v, want a non-auth error", err)
+	} else if !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("404: error %q doesn't mention the status", err)
+	}
+}
+
+func TestFetchCredentialCache(t *testing.T) {
+	server, requests := fakeCredentialServer(t, map[string]string{
+		"1": `{"id":1,"authType":"password","password":"hunter2"}`,
+	})
+	client := NewClient(server.URL, "good-token", 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.FetchCredential(1); err != nil {
+			t.Fatalf("FetchCredential: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests for a cached credential, want 1", got)
+	}
+
+	// Failed fetches aren't cached
+	if _, err := client.FetchCredential(2); err == nil {
+		t.Fatal("FetchCredential(2) succeeded, want 404")
+	}
+	client.FetchCredential(2)
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests after two failed fetches, want 3", got)
+	}
+
+	resetCredentialCache()
+	client.FetchCredential(1)
+	if got := requests.Load(); got != 4 {
+		t.Errorf("got %d requests after resetting the cache, want 4", got)
+	}
+}
+
+func TestResolveCredential(t *testing.T) {
+	server, _ := fakeCredentialServer(t, map[string]string{
+		"7": `{"id":7,"username":"shared","authType":"password","password":"hunter2"}`,
+	})
+	client := NewClient(server.URL, "good-token", 0)
+
+	tests := []struct {
+		override string
+		user     string
+	}{
+		{`false`, "shared"},
+		{`"0"`, "shared"},
+		{`true`, "own"},
+		{`1`, "own"},
+		{`"true"`, "own"},
+	}
+	for _, tt := range tests {
+		var th TermixHost
+		data := `{"credentialId":7,"overrideCredentialUsername":` + tt.override + `}`
+		if err := json.Unmarshal([]byte(data), &th); err != nil {
+			t.Fatalf("override %s: %v", tt.override, err)
+		}
+		host := models.Host{User: "own"}
+		client.resolveCredential(th, &host)
+		if host.User != tt.user || host.Password != "hunter2" {
+			t.Errorf("override %s: got user %q password %q, want %q/hunter2", tt.override, host.User, host.Password, tt.user)
+		}
+	}
+}
+
+func TestFlexBool(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`1`, true},
+		{`0`, false},
+		{`"true"`, true},
+		{`"TRUE"`, true},
+		{`"false"`, false},
+		{`"1"`, true},
+		{`"0"`, false},
+		{`""`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		var b flexBool
+		if err := json.Unmarshal([]byte(tt.input), &b); err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if bool(b) != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.input, b, tt.want)
+		}
+	}
+
+	for _, input := range []string{`2`, `"yes"`, `"maybe"`} {
+		var b flexBool
+		if err := json.Unmarshal([]byte(input), &b); err == nil {
+			t.Errorf("%s: got %v, want an error", input, b)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package terraform reads compute instances from local Terraform state files
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sshbuddy/pkg/models"
+)
+
+// DefaultWorkspace is the workspace of state files outside terraform.tfstate.d
+const DefaultWorkspace = "default"
+
+// DefaultMappings cover the compute instances of common providers
+var DefaultMappings = []models.TerraformMapping{
+	{Type: "aws_instance", Name: "tags.Name", PublicIP: "public_ip", PrivateIP: "private_ip", Tags: "tags"},
+	{Type: "google_compute_instance", Name: "name", PublicIP: "network_interface.0.access_config.0.nat_ip", PrivateIP: "network_interface.0.network_ip", Tags: "labels"},
+	{Type: "azurerm_linux_virtual_machine", Name: "name", PublicIP: "public_ip_address", PrivateIP: "private_ip_address", Tags: "tags"},
+	{Type: "digitalocean_droplet", Name: "name", PublicIP: "ipv4_address", PrivateIP: "ipv4_address_private", Tags: "tags"},
+	{Type: "hcloud_server", Name: "name", PublicIP: "ipv4_address", Tags: "labels"},
+	{Type: "openstack_compute_instance_v2", Name: "name", PublicIP: "access_ip_v4", PrivateIP: "network.0.fixed_ip_v4", Tags: "tags"},
+}
+
+// Options control how instances become hosts
+type Options struct {
+	Mappings  []models.TerraformMapping // Added to DefaultMappings; a mapping replaces the default for its type
+	User      string                    // User for every host; empty lets ssh decide
+	PrivateIP bool                      // Prefer the private address over the public one
+}
+
+// state is the part of a version 4 state file that is read
+type state struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Module    string `json:"module"`
+		Instances []struct {
+			IndexKey   any            `json:"index_key"`
+			Attributes map[string]any `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// Result is what was read from a set of state files
+type Result struct {
+	Hosts    []models.Host
+	Warnings []string
+}
+
+// Load reads the state files matching patterns, which may contain globs
+// and a leading ~/. Each host is tagged with its workspace. Patterns that
+// match nothing are warnings, unless none of them match.
+func Load(patterns []string, opts Options) (Result, error) {
+	var result Result
+
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(expandPath(pattern))
+		if err != nil {
+			return result, fmt.Errorf("invalid state file pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("no state file matches %s", pattern))
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return result, fmt.Errorf("no state file matches %s", strings.Join(patterns, ", "))
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		hosts, skipped, err := LoadFile(path, opts)
+		if err != nil {
+			return result, err
+		}
+		if skipped > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %d instance(s) without an address skipped", path, skipped))
+		}
+		for _, host := range hosts {
+			// The same configuration is usually applied in several workspaces
+			if seen[host.Alias] {
+				base := host.Alias + "-" + Workspace(path)
+				host.Alias = base
+				for n := 2; seen[host.Alias]; n++ {
+					host.Alias = fmt.Sprintf("%s-%d", base, n)
+				}
+			}
+			seen[host.Alias] = true
+			result.Hosts = append(result.Hosts, host)
+		}
+	}
+	return result, nil
+}
+
+// LoadFile reads one state file and returns its hosts and the number of
+// instances that were skipped for lack of an address
+func LoadFile(path string, opts Options) ([]models.Host, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	hosts, skipped, err := Parse(data, Workspace(path), opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return hosts, skipped, nil
+}
+
+// Parse extracts hosts from the contents of a state file
+func Parse(data []byte, workspace string, opts Options) ([]models.Host, int, error) {
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, 0, err
+	}
+	if st.Version != 4 {
+		return nil, 0, fmt.Errorf("unsupported state version %d (only version 4 is supported)", st.Version)
+	}
+
+	mappings := make(map[string]models.TerraformMapping)
+	for _, m := range DefaultMappings {
+		mappings[m.Type] = m
+	}
+	for _, m := range opts.Mappings {
+		mappings[m.Type] = m
+	}
+
+	hosts := []models.Host{}
+	skipped := 0
+	for _, res := range st.Resources {
+		mapping, ok := mappings[res.Type]
+		if res.Mode != "managed" || !ok {
+			continue
+		}
+		for _, inst := range res.Instances {
+			public := lookupString(inst.Attributes, mapping.PublicIP)
+			private := lookupString(inst.Attributes, mapping.PrivateIP)
+			address := public
+			if address == "" || (opts.PrivateIP && private != "") {
+				address = private
+			}
+			if address == "" {
+				skipped++
+				continue
+			}
+
+			alias := lookupString(inst.Attributes, mapping.Name)
+			if alias == "" {
+				alias = res.Name
+				if inst.IndexKey != nil {
+					alias += fmt.Sprintf("-%v", inst.IndexKey)
+				}
+			}
+
+			tags := append([]string{workspace}, lookupTags(inst.Attributes, mapping.Tags)...)
+			hosts = append(hosts, models.Host{
+				Alias:    alias,
+				Hostname: address,
+				User:     opts.User,
+				Tags:     tags,
+				SourceID: workspace + "/" + resourceAddress(res.Module, res.Type, res.Name, inst.IndexKey),
+			})
+		}
+	}
+	return hosts, skipped, nil
+}
+
+// resourceAddress builds a Terraform resource address like
+// module.net.aws_instance.web[0] or aws_instance.web["a"]
+func resourceAddress(module, resType, name string, indexKey any) string {
+	address := resType + "." + name
+	if module != "" {
+		address = module + "." + address
+	}
+	switch key := indexKey.(type) {
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	case float64:
+		address += fmt.Sprintf("[%d]", int(key))
+	}
+	return address
+}
+
+// Workspace returns the workspace of a state file: the directory name for
+// terraform.tfstate.d/<workspace>/terraform.tfstate, otherwise "default"
+func Workspace(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(filepath.Dir(dir)) == "terraform.tfstate.d" {
+		return filepath.Base(dir)
+	}
+	return DefaultWorkspace
+}
+
+// lookup follows a dotted attribute path through maps and lists
+func lookup(attrs map[string]any, path string) any {
+	if path == "" {
+		return nil
+	}
+	var value any = attrs
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[part]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func lookupString(attrs map[string]any, path string) string {
+	switch v := lookup(attrs, path).(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// lookupTags reads a list of tags, or a map of tags/labels as "key=value"
+func lookupTags(attrs map[string]any, path string) []string {
+	var tags []string
+	switch v := lookup(attrs, path).(type) {
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				tags = append(tags, s)
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// The name tag is already the alias
+			if strings.EqualFold(key, "name") {
+				continue
+			}
+			tags = append(tags, fmt.Sprintf("%s=%v", key, v[key]))
+		}
+	}
+	return tags
+}
+
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
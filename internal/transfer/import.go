@@ -0,0 +1,130 @@
+package transfer
+
+import (
+	"strings"
+
+	"sshbuddy/pkg/models"
+)
+
+// ImportStatus says what importing a host would do
+type ImportStatus int
+
+const (
+	ImportNew      ImportStatus = iota // The alias is new and the host is added
+	ImportSame                         // An identical host exists; nothing to do
+	ImportConflict                     // The alias exists with other settings
+	ImportInvalid                      // The host doesn't validate and is skipped
+)
+
+// ImportEntry is one imported host and how it relates to the existing hosts
+type ImportEntry struct {
+	Host     models.Host
+	Status   ImportStatus
+	Existing models.Host // The host with the same alias, for ImportSame and ImportConflict
+	Reason   string      // Why the host is invalid, for ImportInvalid
+}
+
+// ImportPlan is the dry-run result of an import
+type ImportPlan struct {
+	Entries []ImportEntry
+}
+
+// PlanImport compares incoming hosts with the existing ones by alias.
+// Aliases repeated within incoming conflict with their first occurrence.
+// Hosts that wouldn't pass validation once saved are marked invalid, so
+// an import never leaves a config the loader rejects.
+func PlanImport(existing, incoming []models.Host) ImportPlan {
+	known := make(map[string]models.Host, len(existing))
+	for _, host := range existing {
+		known[host.Alias] = host
+	}
+
+	var plan ImportPlan
+	for _, host := range incoming {
+		entry := ImportEntry{Host: host, Status: ImportNew}
+		if reason := invalidReason(host); reason != "" {
+			entry.Status = ImportInvalid
+			entry.Reason = reason
+		} else if other, ok := known[host.Alias]; ok {
+			entry.Existing = other
+			entry.Status = ImportConflict
+			if sameHost(host, other) {
+				entry.Status = ImportSame
+			}
+		} else {
+			known[host.Alias] = host
+		}
+		plan.Entries = append(plan.Entries, entry)
+	}
+	return plan
+}
+
+// Count returns the number of entries with status
+func (p ImportPlan) Count(status ImportStatus) int {
+	n := 0
+	for _, entry := range p.Entries {
+		if entry.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply returns manual with the planned hosts added. Conflicting hosts are
+// skipped unless overwrite is set; then they replace the manual host with
+// the same alias, or shadow a host from another source.
+func (p ImportPlan) Apply(manual []models.Host, overwrite bool) []models.Host {
+	hosts := append([]models.Host(nil), manual...)
+	for _, entry := range p.Entries {
+		host := entry.Host
+		host.Source = "manual"
+		host.SourceID = ""
+
+		switch entry.Status {
+		case ImportNew:
+			hosts = append(hosts, host)
+		case ImportConflict:
+			if !overwrite {
+				continue
+			}
+			replaced := false
+			for i := range hosts {
+				if hosts[i].Alias == host.Alias {
+					hosts[i] = host
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
+
+// invalidReason returns why host fails validation, or "" if it passes
+func invalidReason(host models.Host) string {
+	var reasons []string
+	for _, problem := range host.Validate() {
+		if !problem.IsWarning() {
+			reasons = append(reasons, problem.Message)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// sameHost reports whether a and b connect the same way
+func sameHost(a, b models.Host) bool {
+	port := func(h models.Host) string {
+		if h.Port == "" {
+			return "22"
+		}
+		return h.Port
+	}
+	return a.Hostname == b.Hostname &&
+		a.User == b.User &&
+		port(a) == port(b) &&
+		a.IdentityFile == b.IdentityFile &&
+		a.ProxyJump == b.ProxyJump
+}
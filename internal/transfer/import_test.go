@@ -0,0 +1,58 @@
+package transfer
+
+import (
+	"strings"
+	"testing"
+
+	"sshbuddy/pkg/models"
+)
+
+func TestPlanImportSkipsInvalidHosts(t *testing.T) {
+	incoming, err := Parse([]byte(`Host web
+    HostName 10.0.0.5
+    User deploy
+
+Host nouser
+    HostName 10.0.0.6
+`), FormatSSH)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	plan := PlanImport(nil, incoming)
+	if len(plan.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(plan.Entries))
+	}
+	if entry := plan.Entries[0]; entry.Status != ImportNew {
+		t.Errorf("web: got status %v, want ImportNew", entry.Status)
+	}
+	entry := plan.Entries[1]
+	if entry.Status != ImportInvalid || !strings.Contains(entry.Reason, "user is required") {
+		t.Errorf("nouser: got status %v reason %q, want ImportInvalid for the missing user", entry.Status, entry.Reason)
+	}
+
+	for _, overwrite := range []bool{false, true} {
+		hosts := plan.Apply(nil, overwrite)
+		if len(hosts) != 1 || hosts[0].Alias != "web" {
+			t.Errorf("overwrite %v: Apply saved %+v, want only web", overwrite, hosts)
+		}
+		config := models.Config{Hosts: hosts}
+		if errs := config.Validate(); models.HasErrors(errs) {
+			t.Errorf("overwrite %v: imported config doesn't validate: %v", overwrite, errs)
+		}
+	}
+}
+
+func TestPlanImportInvalidDoesNotConflict(t *testing.T) {
+	existing := []models.Host{{Alias: "db", Hostname: "10.0.0.7", User: "admin"}}
+	incoming := []models.Host{{Alias: "db", Hostname: "10.0.0.8"}}
+
+	plan := PlanImport(existing, incoming)
+	if plan.Entries[0].Status != ImportInvalid {
+		t.Fatalf("got status %v, want ImportInvalid", plan.Entries[0].Status)
+	}
+	hosts := plan.Apply(existing, true)
+	if len(hosts) != 1 || hosts[0].User != "admin" {
+		t.Errorf("an invalid host replaced a valid one: %+v", hosts)
+	}
+}
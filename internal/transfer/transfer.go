@@ -0,0 +1,271 @@
+// Package transfer exports hosts to SSH config, JSON and CSV files and
+// imports them back as manual hosts.
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sshbuddy/internal/atomicfile"
+	"sshbuddy/internal/inventory"
+	"sshbuddy/internal/ssh"
+	"sshbuddy/pkg/models"
+)
+
+// File formats
+const (
+	FormatSSH  = "ssh"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatSSH, FormatJSON, FormatCSV}
+
+// DetectFormat guesses the format of path from its extension. Anything that
+// isn't .json or .csv is read as SSH config.
+func DetectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".csv":
+		return FormatCSV
+	default:
+		return FormatSSH
+	}
+}
+
+// ValidFormat reports whether format is one of Formats
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Export writes hosts to w in format. Secrets are never written. It returns
+// the aliases of hosts left out because the format can't hold them safely.
+func Export(w io.Writer, hosts []models.Host, format string) ([]string, error) {
+	switch format {
+	case FormatSSH:
+		return exportSSH(w, hosts)
+	case FormatJSON:
+		return nil, exportJSON(w, hosts)
+	case FormatCSV:
+		return nil, exportCSV(w, hosts)
+	default:
+		return nil, fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// ErrFileExists is returned by ExportFile when the file is there already
+// and overwriting it wasn't asked for
+var ErrFileExists = errors.New("file already exists")
+
+// ExportFile writes hosts to the file at path, which may start with ~/. An
+// existing file is only replaced when overwrite is set. Like Export, it
+// returns the aliases of hosts that were left out.
+func ExportFile(path string, hosts []models.Host, format string, overwrite bool) ([]string, error) {
+	var buf bytes.Buffer
+	skipped, err := Export(&buf, hosts, format)
+	if err != nil {
+		return nil, err
+	}
+
+	path = inventory.ExpandPath(path)
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s: %w", path, ErrFileExists)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return skipped, atomicfile.WriteFile(path, buf.Bytes(), 0600)
+}
+
+func exportSSH(w io.Writer, hosts []models.Host) ([]string, error) {
+	if _, err := io.WriteString(w, "# Exported by sshbuddy\n"); err != nil {
+		return nil, err
+	}
+	return WriteSSHHosts(w, hosts)
+}
+
+// WriteSSHHosts writes hosts as ssh_config Host blocks, each after a blank
+// line. Values with spaces are quoted. A host with a line break or a double
+// quote in a value is left out, since ssh would read the rest as further
+// options; their aliases are returned.
+func WriteSSHHosts(w io.Writer, hosts []models.Host) ([]string, error) {
+	var b strings.Builder
+	var skipped []string
+	for _, host := range hosts {
+		if !sshSafe(host) {
+			skipped = append(skipped, host.Alias)
+			continue
+		}
+
+		b.WriteString("\n")
+		if len(host.Tags) > 0 {
+			fmt.Fprintf(&b, "# tags: %s\n", oneLine(strings.Join(host.Tags, ", ")))
+		}
+		if host.Note != "" {
+			fmt.Fprintf(&b, "# note: %s\n", oneLine(host.Note))
+		}
+		// A space would make ssh read the alias as several patterns
+		fmt.Fprintf(&b, "Host %s\n", strings.Join(strings.Fields(host.Alias), "-"))
+		writeOption(&b, "HostName", host.Hostname)
+		writeOption(&b, "User", host.User)
+		if host.Port != "22" {
+			writeOption(&b, "Port", host.Port)
+		}
+		writeOption(&b, "IdentityFile", host.IdentityFile)
+		writeOption(&b, "ProxyJump", host.ProxyJump)
+		for _, forward := range host.LocalForwards {
+			// sshbuddy stores "port:host:hostport"; ssh wants "port host:hostport"
+			if port, target, ok := strings.Cut(forward, ":"); ok {
+				writeOption(&b, "LocalForward", port, target)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return skipped, err
+}
+
+// sshSafe reports whether every option value of host can be written to an
+// ssh_config without breaking out of its line or its quotes
+func sshSafe(host models.Host) bool {
+	values := append([]string{host.Hostname, host.User, host.Port, host.IdentityFile, host.ProxyJump}, host.LocalForwards...)
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n\"") {
+			return false
+		}
+	}
+	return true
+}
+
+// oneLine joins the lines of s, so it stays inside a comment
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeOption writes an option with its arguments, quoting those with spaces
+func writeOption(b *strings.Builder, key string, args ...string) {
+	if len(args) == 0 || args[0] == "" {
+		return
+	}
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			args[i] = `"` + arg + `"`
+		}
+	}
+	fmt.Fprintf(b, "    %s %s\n", key, strings.Join(args, " "))
+}
+
+func exportJSON(w io.Writer, hosts []models.Host) error {
+	exported := make([]models.Host, len(hosts))
+	for i, host := range hosts {
+		host.SourceID = ""
+		exported[i] = host
+	}
+	data, err := json.MarshalIndent(struct {
+		Hosts []models.Host `json:"hosts"`
+	}{exported}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func exportCSV(w io.Writer, hosts []models.Host) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"alias", "hostname", "user", "port", "tags", "identity_file", "proxy_jump", "note"})
+	for _, host := range hosts {
+		writer.Write([]string{
+			host.Alias,
+			host.Hostname,
+			host.User,
+			host.Port,
+			strings.Join(host.Tags, ";"),
+			host.IdentityFile,
+			host.ProxyJump,
+			host.Note,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Parse reads hosts in format from data
+func Parse(data []byte, format string) ([]models.Host, error) {
+	switch format {
+	case FormatSSH:
+		return parseSSH(data)
+	case FormatJSON:
+		return inventory.ParseHostList(data, false)
+	case FormatCSV:
+		return inventory.ParseCSV(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// ParseFile reads hosts from the file at path, or stdin if path is "-".
+// An empty format is guessed from the file extension.
+func ParseFile(path, format string) ([]models.Host, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(inventory.ExpandPath(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = DetectFormat(path)
+	}
+	return Parse(data, format)
+}
+
+// parseSSH reads Host blocks from SSH config content. Wildcard patterns are
+// skipped, and only the first of several patterns becomes the alias.
+func parseSSH(data []byte) ([]models.Host, error) {
+	entries, err := ssh.ParseSSHConfigReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := []models.Host{}
+	for _, entry := range entries {
+		alias := strings.Fields(entry.Host)[0]
+		if strings.ContainsAny(alias, "*?!") {
+			continue
+		}
+		host := models.Host{
+			Alias:        alias,
+			Hostname:     entry.HostName,
+			User:         entry.User,
+			Port:         entry.Port,
+			IdentityFile: entry.IdentityFile,
+			ProxyJump:    entry.ProxyJump,
+		}
+		if host.Hostname == "" {
+			host.Hostname = alias
+		}
+		if port, target, ok := strings.Cut(entry.LocalForward, " "); ok {
+			host.LocalForwards = []string{port + ":" + strings.TrimSpace(target)}
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
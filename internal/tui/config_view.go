@@ -2,8 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"sshbuddy/internal/config"
+	"sshbuddy/internal/target"
 	"sshbuddy/pkg/models"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -13,43 +15,46 @@ import (
 
 // SourceConfig represents configuration for a data source
 type SourceConfig struct {
-	Name        string
-	Enabled     bool
-	Description string
-	Configurable bool // Whether this source has additional config
+	Name         string
+	Enabled      bool
+	Description  string
+	Configurable bool                      // Whether this source has additional config
+	Source       config.ConfigurableSource // Nil for settings that aren't sources (Theme)
 }
 
 // ConfigViewModel handles the configuration UI
 type ConfigViewModel struct {
-	sources         []SourceConfig
-	config          *models.Config
-	focusIndex      int // Which source/setting is focused
-	editingTermix   bool
-	editingSSHConfig bool
-	termixInputs    []textinput.Model
-	sshConfigInputs []textinput.Model
-	termixFocus     int
-	sshConfigFocus  int
-	width           int
-	height          int
-	saved           bool
-	errorMsg        string
+	sources      []SourceConfig
+	config       *models.Config
+	focusIndex   int                       // Which source/setting is focused
+	editing      config.ConfigurableSource // Source whose settings are being edited
+	addKind      *config.SourceKind        // Kind of the edited source when it is new; dropped on cancel
+	fields       []config.SourceField
+	inputs       []textinput.Model
+	fieldFocus   int
+	choosingKind bool // Picking the kind of source to add
+	addKinds     []config.SourceKind
+	kindFocus    int
+	restoring    bool            // Picking a backup to restore
+	backups      []config.Backup // Newest first
+	backupFocus  int
+	restored     string // Time of the backup just restored, for the status line
+	width        int
+	height       int
+	saved        bool
+	errorMsg     string
 }
 
 // NewConfigViewModel creates a new configuration view model
 func NewConfigViewModel() ConfigViewModel {
-	// Load current config
-	cfg, err := config.LoadConfig()
+	// Load current config (file only, sources aren't needed here)
+	cfg, err := config.LoadConfigRaw()
 	if err != nil {
 		cfg = &models.Config{
 			Hosts: []models.Host{},
 			Sources: models.SourcesConfig{
 				SSHBuddyEnabled:  true,
 				SSHConfigEnabled: true,
-				TermixEnabled:    false,
-			},
-			Termix: models.TermixConfig{
-				Enabled: false,
 			},
 			SSH: models.SSHConfig{
 				Enabled: true,
@@ -57,64 +62,107 @@ func NewConfigViewModel() ConfigViewModel {
 		}
 	}
 
-	// Define sources and settings
-	sources := []SourceConfig{
-		{
-			Name:         "SSHBuddy",
-			Enabled:      cfg.Sources.SSHBuddyEnabled,
-			Description:  "Hosts added manually through SSHBuddy",
-			Configurable: true,
-		},
-		{
-			Name:         "SSH Config",
-			Enabled:      cfg.Sources.SSHConfigEnabled,
-			Description:  "Hosts from ~/.ssh/config",
-			Configurable: true,
-		},
-		{
-			Name:         "Termix",
-			Enabled:      cfg.Sources.TermixEnabled,
-			Description:  "Hosts from Termix API server",
-			Configurable: true,
-		},
-		{
-			Name:         "Theme",
-			Enabled:      true, // Always enabled, just shows current theme
-			Description:  fmt.Sprintf("Current: %s", GetCurrentTheme().Name),
-			Configurable: true,
-		},
+	// Source kinds that can have sources added from here
+	var addKinds []config.SourceKind
+	for _, kind := range config.SourceKinds() {
+		if kind.Add != nil {
+			addKinds = append(addKinds, kind)
+		}
 	}
 
-	// Create Termix input fields (only base URL, credentials are prompted when needed)
-	termixInputs := make([]textinput.Model, 1)
-	
-	// Base URL input
-	termixInputs[0] = textinput.New()
-	termixInputs[0].Placeholder = "https://termix.example.com/api"
-	termixInputs[0].SetValue(cfg.Termix.BaseURL)
-	termixInputs[0].CharLimit = 200
-	termixInputs[0].Width = 50
+	return ConfigViewModel{
+		sources:    buildSourceList(cfg),
+		config:     cfg,
+		focusIndex: 0,
+		addKinds:   addKinds,
+	}
+}
 
-	// Create SSH Config input fields
-	sshConfigInputs := make([]textinput.Model, 1)
+// buildSourceList lists the configured sources followed by the other settings
+func buildSourceList(cfg *models.Config) []SourceConfig {
+	var sources []SourceConfig
+	for _, source := range config.AllSources(cfg) {
+		configurable, ok := source.(config.ConfigurableSource)
+		if !ok {
+			continue
+		}
+		sources = append(sources, SourceConfig{
+			Name:         configurable.Label(),
+			Enabled:      configurable.Enabled(),
+			Description:  configurable.Description(),
+			Configurable: true,
+			Source:       configurable,
+		})
+	}
 	
-	// Config Path input
-	sshConfigInputs[0] = textinput.New()
-	sshConfigInputs[0].Placeholder = "~/.ssh/config (leave empty for default)"
-	sshConfigInputs[0].SetValue(cfg.SSH.ConfigPath)
-	sshConfigInputs[0].CharLimit = 300
-	sshConfigInputs[0].Width = 50
+	return append(sources, SourceConfig{
+		Name:         "Theme",
+		Enabled:      true, // Always enabled, just shows current theme
+		Description:  fmt.Sprintf("Current: %s", GetCurrentTheme().Name),
+		Configurable: true,
+	}, SourceConfig{
+		Name:         "ssh_config File",
+		Enabled:      cfg.ManagedConfig.Enabled,
+		Description:  managedConfigDescription(cfg.ManagedConfig),
+		Configurable: true,
+	}, SourceConfig{
+		Name:         "After Session",
+		Enabled:      cfg.ReturnToList,
+		Description:  afterSessionDescription(cfg.ReturnToList),
+		Configurable: true,
+	}, SourceConfig{
+		Name:         "Open Sessions In",
+		Enabled:      true,
+		Description:  connectTargetDescription(cfg.Connect),
+		Configurable: true,
+	})
+}
 
-	return ConfigViewModel{
-		sources:          sources,
-		config:           cfg,
-		focusIndex:       0,
-		editingTermix:    false,
-		editingSSHConfig: false,
-		termixInputs:     termixInputs,
-		sshConfigInputs:  sshConfigInputs,
-		termixFocus:      0,
-		sshConfigFocus:   0,
+// connectTargetDescription describes the default connection target
+func connectTargetDescription(connect models.ConnectConfig) string {
+	name := connect.Target
+	if name == "" {
+		name = models.TargetTerminal
+	}
+	if target.Default(connect) != name {
+		return target.Label(name) + " (not available here, so this terminal)"
+	}
+	return target.Label(name)
+}
+
+// nextConnectTarget returns the target after the current default, skipping
+// the emulator when no emulator command is configured
+func nextConnectTarget(connect models.ConnectConfig) string {
+	current := slices.Index(models.ConnectTargets, connect.Target)
+	if current < 0 {
+		current = 0
+	}
+	for i := 1; i <= len(models.ConnectTargets); i++ {
+		next := models.ConnectTargets[(current+i)%len(models.ConnectTargets)]
+		if next != models.TargetEmulator || strings.TrimSpace(connect.Emulator) != "" {
+			return next
+		}
+	}
+	return models.TargetTerminal
+}
+
+// afterSessionDescription describes what happens when an SSH session ends
+func afterSessionDescription(returnToList bool) string {
+	if returnToList {
+		return "Return to the host list"
+	}
+	return "Exit sshbuddy"
+}
+
+// managedConfigDescription describes the generated ssh_config setting
+func managedConfigDescription(managed models.ManagedSSHConfig) string {
+	switch {
+	case managed.Enabled && managed.Include:
+		return "Generated and included from ~/.ssh/config"
+	case managed.Enabled:
+		return "Generated at ~/.config/sshbuddy/ssh_config (not included)"
+	default:
+		return "Off - hosts only work through sshbuddy"
 	}
 }
 
@@ -128,95 +176,118 @@ func (m ConfigViewModel) Update(msg tea.Msg) (ConfigViewModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// If editing SSH Config
-		if m.editingSSHConfig {
+		// If picking the kind of source to add
+		if m.choosingKind {
 			switch msg.String() {
 			case "esc":
-				m.editingSSHConfig = false
-				m.errorMsg = ""
-				return m, nil
-			case "tab", "shift+tab", "up", "down":
-				// Only one input for SSH Config, so no navigation needed
-				return m, nil
+				m.choosingKind = false
+			case "up", "k":
+				if m.kindFocus > 0 {
+					m.kindFocus--
+				}
+			case "down", "j":
+				if m.kindFocus < len(m.addKinds)-1 {
+					m.kindFocus++
+				}
 			case "enter":
-				// Save SSH Config
-				m.config.SSH.ConfigPath = strings.TrimSpace(m.sshConfigInputs[0].Value())
-				
-				// Save to file
-				if err := config.SaveConfig(m.config); err != nil {
-					m.errorMsg = fmt.Sprintf("Failed to save: %v", err)
+				m.choosingKind = false
+				m.addSource(m.addKinds[m.kindFocus])
+			}
+			return m, nil
+		}
+		
+		// If picking a backup to restore
+		if m.restoring {
+			switch msg.String() {
+			case "esc":
+				m.restoring = false
+			case "up", "k":
+				if m.backupFocus > 0 {
+					m.backupFocus--
+				}
+			case "down", "j":
+				if m.backupFocus < len(m.backups)-1 {
+					m.backupFocus++
+				}
+			case "enter":
+				if len(m.backups) == 0 {
+					m.restoring = false
 					return m, nil
 				}
-				
-				m.editingSSHConfig = false
-				m.saved = true
-				m.errorMsg = ""
-				return m, nil
+				m.restoreBackup(m.backups[m.backupFocus])
 			}
-			
-			// Update the input
-			m.sshConfigInputs[0], cmd = m.sshConfigInputs[0].Update(msg)
-			return m, cmd
+			return m, nil
 		}
 		
-		// If editing Termix config
-		if m.editingTermix {
+		// If editing a source's settings
+		if m.editing != nil {
 			switch msg.String() {
 			case "esc":
-				m.editingTermix = false
+				if removable, ok := m.editing.(config.RemovableSource); ok && m.addKind != nil {
+					removable.Remove()
+					m.sources = buildSourceList(m.config)
+					m.clampFocus()
+				}
+				m.editing = nil
+				m.addKind = nil
 				m.errorMsg = ""
 				return m, nil
 			case "tab", "shift+tab", "up", "down":
 				// Navigate between inputs
 				if msg.String() == "up" || msg.String() == "shift+tab" {
-					m.termixFocus--
+					m.fieldFocus--
 				} else {
-					m.termixFocus++
+					m.fieldFocus++
 				}
 				
-				if m.termixFocus < 0 {
-					m.termixFocus = len(m.termixInputs) - 1
-				} else if m.termixFocus >= len(m.termixInputs) {
-					m.termixFocus = 0
+				if m.fieldFocus < 0 {
+					m.fieldFocus = len(m.inputs) - 1
+				} else if m.fieldFocus >= len(m.inputs) {
+					m.fieldFocus = 0
 				}
 				
 				// Update focus
-				for i := range m.termixInputs {
-					if i == m.termixFocus {
-						m.termixInputs[i].Focus()
+				for i := range m.inputs {
+					if i == m.fieldFocus {
+						m.inputs[i].Focus()
 					} else {
-						m.termixInputs[i].Blur()
+						m.inputs[i].Blur()
 					}
 				}
 				return m, nil
 			case "enter":
-				// Save Termix config
-				m.config.Termix.BaseURL = strings.TrimSpace(m.termixInputs[0].Value())
-				
-				// Validate
-				if m.config.Termix.Enabled && m.config.Termix.BaseURL == "" {
-					m.errorMsg = "Base URL is required when Termix is enabled"
-					return m, nil
+				// Validate and apply the source's settings
+				values := make([]string, len(m.inputs))
+				for i, input := range m.inputs {
+					values[i] = input.Value()
 				}
-				
-				// Save to file
-				if err := config.SaveConfig(m.config); err != nil {
-					m.errorMsg = fmt.Sprintf("Failed to save: %v", err)
+				name, addKind := m.editing.Name(), m.addKind
+				var edited config.ConfigurableSource
+				ok := m.updateConfig(func(cfg *models.Config) error {
+					source, err := editedSource(cfg, name, addKind)
+					if err != nil {
+						return err
+					}
+					edited = source
+					return source.SetFields(values)
+				})
+				if !ok {
 					return m, nil
 				}
+				commitSource(edited)
 				
-				m.editingTermix = false
-				m.saved = true
-				m.errorMsg = ""
+				m.editing = nil
+				m.addKind = nil
 				return m, nil
 			}
 			
 			// Update the focused input
-			m.termixInputs[m.termixFocus], cmd = m.termixInputs[m.termixFocus].Update(msg)
+			m.inputs[m.fieldFocus], cmd = m.inputs[m.fieldFocus].Update(msg)
 			return m, cmd
 		}
 		
 		// Normal navigation
+		m.restored = ""
 		switch msg.String() {
 		case "up", "k":
 			if m.focusIndex > 0 {
@@ -254,55 +325,99 @@ func (m ConfigViewModel) Update(msg tea.Msg) (ConfigViewModel, tea.Cmd) {
 				
 				// Apply and save theme
 				ApplyTheme(newTheme)
-				m.config.Theme = newTheme
-				
-				// Update description to show new theme
-				m.sources[m.focusIndex].Description = fmt.Sprintf("Current: %s", GetCurrentTheme().Name)
-				
-				if err := config.SaveConfig(m.config); err != nil {
-					m.errorMsg = fmt.Sprintf("Failed to save: %v", err)
-					m.saved = false
-				} else {
-					m.saved = true
-					m.errorMsg = ""
+				m.updateConfig(func(cfg *models.Config) error {
+					cfg.Theme = newTheme
+					return nil
+				})
+			} else if m.sources[m.focusIndex].Name == "ssh_config File" {
+				// Cycle off -> generated -> generated and included
+				managed := m.config.ManagedConfig
+				switch {
+				case managed.Enabled && managed.Include:
+					managed.Enabled, managed.Include = false, false
+				case managed.Enabled:
+					managed.Include = true
+				default:
+					managed.Enabled = true
 				}
-			} else if m.sources[m.focusIndex].Configurable {
-				// Toggle enabled state for sources
-				m.sources[m.focusIndex].Enabled = !m.sources[m.focusIndex].Enabled
-				
-				// Update config
-				m.config.Sources.SSHBuddyEnabled = m.sources[0].Enabled
-				m.config.Sources.SSHConfigEnabled = m.sources[1].Enabled
-				m.config.Sources.TermixEnabled = m.sources[2].Enabled
-				
-				// Also update Termix enabled if it's the Termix source
-				if m.sources[m.focusIndex].Name == "Termix" {
-					m.config.Termix.Enabled = m.sources[m.focusIndex].Enabled
-				}
-				
-				if err := config.SaveConfig(m.config); err != nil {
-					m.errorMsg = fmt.Sprintf("Failed to save: %v", err)
-					m.saved = false
-				} else {
-					m.saved = true
-					m.errorMsg = ""
+				m.updateConfig(func(cfg *models.Config) error {
+					cfg.ManagedConfig = managed
+					return nil
+				})
+			} else if m.sources[m.focusIndex].Name == "Open Sessions In" {
+				next := nextConnectTarget(m.config.Connect)
+				if next == models.TargetTerminal {
+					next = ""
 				}
+				m.updateConfig(func(cfg *models.Config) error {
+					cfg.Connect.Target = next
+					return nil
+				})
+			} else if m.sources[m.focusIndex].Name == "After Session" {
+				returnToList := !m.config.ReturnToList
+				m.updateConfig(func(cfg *models.Config) error {
+					cfg.ReturnToList = returnToList
+					return nil
+				})
+			} else if source := m.sources[m.focusIndex].Source; source != nil {
+				// Toggle enabled state for sources
+				name, enabled := source.Name(), !source.Enabled()
+				m.updateConfig(func(cfg *models.Config) error {
+					source, err := editedSource(cfg, name, nil)
+					if err != nil {
+						return err
+					}
+					return source.SetEnabled(enabled)
+				})
 			}
 		case "e":
 			// Edit configuration for the selected source (not for Theme)
-			if m.sources[m.focusIndex].Configurable && m.sources[m.focusIndex].Name != "Theme" {
-				if m.sources[m.focusIndex].Name == "Termix" {
-					m.editingTermix = true
-					m.termixFocus = 0
-					m.termixInputs[0].Focus()
-					m.saved = false
-					return m, nil
-				} else if m.sources[m.focusIndex].Name == "SSH Config" {
-					m.editingSSHConfig = true
-					m.sshConfigFocus = 0
-					m.sshConfigInputs[0].Focus()
-					m.saved = false
-					return m, nil
+			if source := m.sources[m.focusIndex].Source; source != nil && len(source.Fields()) > 0 {
+				m.startEdit(source)
+				return m, nil
+			}
+		case "r":
+			// Restore the config file from a backup
+			backups, err := config.ListBackups()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Failed to list backups: %v", err)
+				m.saved = false
+				return m, nil
+			}
+			m.backups = backups
+			m.backupFocus = 0
+			m.restoring = true
+			m.saved = false
+			m.restored = ""
+			m.errorMsg = ""
+			return m, nil
+		case "a":
+			// Add a source, asking for its kind if there is more than one
+			switch len(m.addKinds) {
+			case 0:
+			case 1:
+				m.addSource(m.addKinds[0])
+			default:
+				m.choosingKind = true
+				m.kindFocus = 0
+				m.saved = false
+			}
+			return m, nil
+		case "d":
+			// Remove the selected source, if it can be removed
+			if _, ok := m.sources[m.focusIndex].Source.(config.RemovableSource); ok {
+				name := m.sources[m.focusIndex].Source.Name()
+				var removed config.ConfigurableSource
+				if m.updateConfig(func(cfg *models.Config) error {
+					source, err := editedSource(cfg, name, nil)
+					if err != nil {
+						return err
+					}
+					removed = source
+					source.(config.RemovableSource).Remove()
+					return nil
+				}) {
+					commitSource(removed)
 				}
 			}
 		}
@@ -313,26 +428,157 @@ func (m ConfigViewModel) Update(msg tea.Msg) (ConfigViewModel, tea.Cmd) {
 	}
 
 	// Update all inputs for blinking cursor
-	for i := range m.termixInputs {
-		m.termixInputs[i], cmd = m.termixInputs[i].Update(msg)
+	for i := range m.inputs {
+		m.inputs[i], cmd = m.inputs[i].Update(msg)
 		cmds = append(cmds, cmd)
 	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// startEdit opens the settings form of source
+func (m *ConfigViewModel) startEdit(source config.ConfigurableSource) {
+	m.editing = source
+	m.fields = source.Fields()
+	m.inputs = make([]textinput.Model, len(m.fields))
+	for i, field := range m.fields {
+		m.inputs[i] = textinput.New()
+		m.inputs[i].Placeholder = field.Placeholder
+		m.inputs[i].SetValue(field.Value)
+		m.inputs[i].CharLimit = 300
+		m.inputs[i].Width = 50
+	}
+	m.fieldFocus = 0
+	if len(m.inputs) > 0 {
+		m.inputs[0].Focus()
+	}
+	m.saved = false
+	m.errorMsg = ""
+}
+
+// addSource creates a source of kind and opens its settings; it is dropped
+// again if the form is cancelled
+func (m *ConfigViewModel) addSource(kind config.SourceKind) {
+	source, ok := kind.Add(m.config).(config.ConfigurableSource)
+	if !ok {
+		return
+	}
+	m.sources = buildSourceList(m.config)
 	
-	for i := range m.sshConfigInputs {
-		m.sshConfigInputs[i], cmd = m.sshConfigInputs[i].Update(msg)
-		cmds = append(cmds, cmd)
+	// Keep the new source selected once the form closes
+	for i, entry := range m.sources {
+		if entry.Source != nil && entry.Source.Name() == source.Name() {
+			m.focusIndex = i
+		}
 	}
+	
+	m.startEdit(source)
+	m.addKind = &kind
+}
 
-	return m, tea.Batch(cmds...)
+// editedSource returns the source called name in cfg. With addKind set,
+// the source is new and is added to cfg first.
+func editedSource(cfg *models.Config, name string, addKind *config.SourceKind) (config.ConfigurableSource, error) {
+	if addKind != nil {
+		source, _ := addKind.Add(cfg).(config.ConfigurableSource)
+		return source, nil
+	}
+	source, ok := config.FindSource(cfg, name).(config.ConfigurableSource)
+	if !ok {
+		return nil, fmt.Errorf("%s was removed from the config file meanwhile", name)
+	}
+	return source, nil
+}
+
+// updateConfig applies change to the config file, under the config lock so
+// that changes other sshbuddy processes made meanwhile are kept, and shows
+// the saved config. It reports whether the change was saved.
+func (m *ConfigViewModel) updateConfig(change func(cfg *models.Config) error) bool {
+	var changeErr error
+	var saved *models.Config
+	err := config.UpdateConfig(func(cfg *models.Config) error {
+		if changeErr = change(cfg); changeErr != nil {
+			return changeErr
+		}
+		saved = cfg
+		return nil
+	})
+	switch {
+	case changeErr != nil:
+		// Invalid settings, shown as they are
+		m.errorMsg = changeErr.Error()
+		m.saved = false
+		return false
+	case err != nil:
+		m.errorMsg = fmt.Sprintf("Failed to save: %v", err)
+		m.saved = false
+		return false
+	}
+
+	m.config = saved
+	m.sources = buildSourceList(m.config)
+	m.clampFocus()
+	m.saved = true
+	m.errorMsg = ""
+	return true
+}
+
+// commitSource makes the changes to source outside the config file, once
+// the config holding its new settings is saved
+func commitSource(source config.ConfigurableSource) {
+	if committable, ok := source.(config.CommittableSource); ok {
+		committable.Commit()
+	}
+}
+
+// restoreBackup replaces the config file with backup and reloads it
+func (m *ConfigViewModel) restoreBackup(backup config.Backup) {
+	m.restoring = false
+	if err := config.RestoreBackup(backup.Path); err != nil {
+		m.errorMsg = fmt.Sprintf("Restore failed: %v", err)
+		return
+	}
+	
+	cfg, err := config.LoadConfigRaw()
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Restored, but loading failed: %v", err)
+		return
+	}
+	m.config = cfg
+	themeName := cfg.Theme
+	if themeName == "" {
+		themeName = "purple"
+	}
+	ApplyTheme(themeName)
+	m.sources = buildSourceList(cfg)
+	m.clampFocus()
+	m.restored = backup.Time.Format("Jan 2 15:04:05")
+	m.errorMsg = ""
+}
+
+// inSubview reports whether a picker or settings form is open over the list
+func (m ConfigViewModel) inSubview() bool {
+	return m.choosingKind || m.restoring || m.editing != nil
+}
+
+// clampFocus keeps the focus on an existing entry after removals
+func (m *ConfigViewModel) clampFocus() {
+	if m.focusIndex >= len(m.sources) {
+		m.focusIndex = len(m.sources) - 1
+	}
 }
 
 func (m ConfigViewModel) View() string {
-	if m.editingTermix {
-		return m.renderTermixEdit()
+	if m.choosingKind {
+		return m.renderKindPicker()
+	}
+	
+	if m.restoring {
+		return m.renderBackupPicker()
 	}
 	
-	if m.editingSSHConfig {
-		return m.renderSSHConfigEdit()
+	if m.editing != nil {
+		return m.renderSourceEdit()
 	}
 	
 	const boxWidth = 80
@@ -373,7 +619,11 @@ func (m ConfigViewModel) View() string {
 	
 	// Status message
 	var statusMsg string
-	if m.saved {
+	if m.restored != "" {
+		statusMsg = lipgloss.NewStyle().
+			Foreground(accentColor).
+			Render("✓ Configuration restored from the backup of " + m.restored)
+	} else if m.saved {
 		statusMsg = lipgloss.NewStyle().
 			Foreground(accentColor).
 			Render("✓ Configuration saved")
@@ -388,8 +638,15 @@ func (m ConfigViewModel) View() string {
 		keyStyle.Render("↑↓") + descStyle.Render(":navigate "),
 		keyStyle.Render("space") + descStyle.Render(":toggle "),
 		keyStyle.Render("e") + descStyle.Render(":edit "),
-		keyStyle.Render("esc") + descStyle.Render(":back"),
+		keyStyle.Render("r") + descStyle.Render(":restore "),
+	}
+	if len(m.addKinds) > 0 {
+		keyBindings = append(keyBindings,
+			keyStyle.Render("a")+descStyle.Render(":add source "),
+			keyStyle.Render("d")+descStyle.Render(":remove "),
+		)
 	}
+	keyBindings = append(keyBindings, keyStyle.Render("esc")+descStyle.Render(":back"))
 	footer := lipgloss.NewStyle().
 		Border(lipgloss.NormalBorder(), true, false, false, false).
 		BorderForeground(borderColor).
@@ -434,8 +691,8 @@ func (m ConfigViewModel) View() string {
 func (m ConfigViewModel) renderSource(source SourceConfig, isSelected bool) string {
 	// Status indicator
 	var statusIcon string
-	if source.Name == "Theme" {
-		// Diamond icon with theme color for Theme option
+	if source.Name == "Theme" || source.Name == "Open Sessions In" {
+		// Diamond icon with theme color for settings that cycle through values
 		statusIcon = lipgloss.NewStyle().Foreground(primaryColor).Render("◆")
 	} else if source.Enabled {
 		statusIcon = lipgloss.NewStyle().Foreground(accentColor).Render("✓")
@@ -461,11 +718,16 @@ func (m ConfigViewModel) renderSource(source SourceConfig, isSelected bool) stri
 	// Configurable indicator
 	var configIndicator string
 	if source.Configurable && isSelected {
-		if source.Name == "Termix" || source.Name == "SSH Config" {
+		_, removable := source.Source.(config.RemovableSource)
+		if source.Source != nil && removable {
+			configIndicator = lipgloss.NewStyle().
+				Foreground(mutedColor).
+				Render(" (press 'e' to edit, 'd' to remove)")
+		} else if source.Source != nil && len(source.Source.Fields()) > 0 {
 			configIndicator = lipgloss.NewStyle().
 				Foreground(mutedColor).
 				Render(" (press 'e' to edit)")
-		} else if source.Name == "Theme" {
+		} else if source.Name == "Theme" || source.Name == "ssh_config File" || source.Name == "After Session" || source.Name == "Open Sessions In" {
 			configIndicator = lipgloss.NewStyle().
 				Foreground(mutedColor).
 				Render(" (press space/enter to cycle)")
@@ -499,7 +761,8 @@ func (m ConfigViewModel) renderSource(source SourceConfig, isSelected bool) stri
 	return lipgloss.JoinVertical(lipgloss.Left, titleLine, desc, "")
 }
 
-func (m ConfigViewModel) renderTermixEdit() string {
+// renderSourceEdit renders the settings form of the source being edited
+func (m ConfigViewModel) renderSourceEdit() string {
 	const boxWidth = 80
 	
 	// ASCII art header (same as main screen)
@@ -512,12 +775,12 @@ func (m ConfigViewModel) renderTermixEdit() string {
 ╚═╗└─┐├─┤  ╠╩╗│ │ ││ ││└┬┘
 ╚═╝└─┘┴ ┴  ╚═╝└─┘─┴┘─┴┘ ┴`)
 	
-	// Termix Configuration subheading
+	// Source configuration subheading
 	subheading := lipgloss.NewStyle().
 		Foreground(dimColor).
 		Width(boxWidth - 4).
 		Align(lipgloss.Center).
-		Render("Termix Configuration")
+		Render(m.editing.Label() + " Configuration")
 	
 	separator := lipgloss.NewStyle().
 		Foreground(dimColor).
@@ -528,16 +791,19 @@ func (m ConfigViewModel) renderTermixEdit() string {
 	header := lipgloss.JoinVertical(lipgloss.Left, asciiArt, subheading, separator)
 	
 	// Form fields
-	fields := []string{
-		m.renderField("Base URL", m.termixInputs[0], 0, "API endpoint (e.g., https://termix.example.com/api)"),
+	var fields []string
+	for i, field := range m.fields {
+		fields = append(fields, m.renderField(field.Label, m.inputs[i], i, field.Hint))
 	}
 	
 	// Add note about credentials
-	credNote := lipgloss.NewStyle().
-		Foreground(mutedColor).
-		Italic(true).
-		Render("Note: Credentials will be prompted when needed and not stored.")
-	fields = append(fields, credNote)
+	if kind, _ := config.SplitSourceName(m.editing.Name()); kind == config.SourceTermix {
+		credNote := lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Italic(true).
+			Render("Note: Credentials will be prompted when needed and not stored.")
+		fields = append(fields, credNote)
+	}
 	
 	formContent := lipgloss.JoinVertical(lipgloss.Left, fields...)
 	
@@ -549,7 +815,7 @@ func (m ConfigViewModel) renderTermixEdit() string {
 			Render("✗ " + m.errorMsg)
 	}
 	
-	// Footer (in Termix edit view)
+	// Footer (in source edit view)
 	keyBindings := []string{
 		keyStyle.Render("↑↓/tab") + descStyle.Render(":navigate "),
 		keyStyle.Render("enter") + descStyle.Render(":save "),
@@ -597,7 +863,7 @@ func (m ConfigViewModel) renderTermixEdit() string {
 }
 
 func (m ConfigViewModel) renderField(label string, input textinput.Model, index int, hint string) string {
-	isFocused := m.termixFocus == index
+	isFocused := m.fieldFocus == index
 	
 	// Label
 	labelStyle := lipgloss.NewStyle().Foreground(textColor).Bold(true)
@@ -623,25 +889,79 @@ func (m ConfigViewModel) renderField(label string, input textinput.Model, index
 	)
 }
 
-func (m ConfigViewModel) renderSSHConfigEdit() string {
-	const boxWidth = 80
+// renderKindPicker renders the choice of source kind to add
+func (m ConfigViewModel) renderKindPicker() string {
+	const boxWidth = 60
 	
-	// ASCII art header (same as main screen)
-	asciiArt := lipgloss.NewStyle().
+	title := lipgloss.NewStyle().
 		Foreground(primaryColor).
 		Bold(true).
 		Width(boxWidth - 4).
 		Align(lipgloss.Center).
-		Render(`╔═╗┌─┐┬ ┬  ╔╗ ┬ ┬┌┬┐┌┬┐┬ ┬
-╚═╗└─┐├─┤  ╠╩╗│ │ ││ ││└┬┘
-╚═╝└─┘┴ ┴  ╚═╝└─┘─┴┘─┴┘ ┴`)
+		Render("Add Source")
 	
-	// SSH Config Configuration subheading
-	subheading := lipgloss.NewStyle().
+	separator := lipgloss.NewStyle().
 		Foreground(dimColor).
 		Width(boxWidth - 4).
 		Align(lipgloss.Center).
-		Render("SSH Config Configuration")
+		Render(strings.Repeat("─", boxWidth-4))
+	
+	var items []string
+	for i, kind := range m.addKinds {
+		if i == m.kindFocus {
+			items = append(items, lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Bold(true).
+				Render("▸ "+kind.Label))
+		} else {
+			items = append(items, lipgloss.NewStyle().
+				Foreground(textColor).
+				Render("  "+kind.Label))
+		}
+	}
+	
+	// Footer
+	keyBindings := []string{
+		keyStyle.Render("↑↓") + descStyle.Render(":navigate "),
+		keyStyle.Render("enter") + descStyle.Render(":add "),
+		keyStyle.Render("esc") + descStyle.Render(":cancel"),
+	}
+	footer := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), true, false, false, false).
+		BorderForeground(borderColor).
+		Width(boxWidth - 4).
+		Padding(0, 0).
+		Render(lipgloss.JoinHorizontal(lipgloss.Left, keyBindings...))
+	
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		separator,
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, items...),
+		"",
+		footer,
+	)
+	
+	mainBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Width(boxWidth).
+		Padding(1, 2).
+		Render(content)
+	
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mainBox)
+}
+
+// renderBackupPicker renders the list of config backups to restore
+func (m ConfigViewModel) renderBackupPicker() string {
+	const boxWidth = 60
+	
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Width(boxWidth - 4).
+		Align(lipgloss.Center).
+		Render("Restore Configuration")
 	
 	separator := lipgloss.NewStyle().
 		Foreground(dimColor).
@@ -649,24 +969,39 @@ func (m ConfigViewModel) renderSSHConfigEdit() string {
 		Align(lipgloss.Center).
 		Render(strings.Repeat("─", boxWidth-4))
 	
-	header := lipgloss.JoinVertical(lipgloss.Left, asciiArt, subheading, separator)
-	
-	// Form field
-	field := m.renderField("Config Path", m.sshConfigInputs[0], 0, "Path to SSH config file (leave empty for default ~/.ssh/config)")
-	
-	formContent := lipgloss.JoinVertical(lipgloss.Left, field)
-	
-	// Error message
-	var errorMsg string
-	if m.errorMsg != "" {
-		errorMsg = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Render("✗ " + m.errorMsg)
+	var items []string
+	if len(m.backups) == 0 {
+		items = append(items, lipgloss.NewStyle().
+			Foreground(dimColor).
+			Italic(true).
+			Render("No backups yet. One is made each time the config changes."))
+	}
+	for i, backup := range m.backups {
+		hosts := "unreadable"
+		if backup.Hosts >= 0 {
+			hosts = fmt.Sprintf("%d manual host(s)", backup.Hosts)
+		}
+		label := fmt.Sprintf("%s  %s", backup.Time.Format("Mon Jan 2 15:04:05"), hosts)
+		if i == m.backupFocus {
+			items = append(items, lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Bold(true).
+				Render("▸ "+label))
+		} else {
+			items = append(items, lipgloss.NewStyle().
+				Foreground(textColor).
+				Render("  "+label))
+		}
 	}
 	
+	hint := lipgloss.NewStyle().
+		Foreground(dimColor).
+		Render("The current config is backed up before it is replaced.")
+	
 	// Footer
 	keyBindings := []string{
-		keyStyle.Render("enter") + descStyle.Render(":save "),
+		keyStyle.Render("↑↓") + descStyle.Render(":navigate "),
+		keyStyle.Render("enter") + descStyle.Render(":restore "),
 		keyStyle.Render("esc") + descStyle.Render(":cancel"),
 	}
 	footer := lipgloss.NewStyle().
@@ -676,36 +1011,23 @@ func (m ConfigViewModel) renderSSHConfigEdit() string {
 		Padding(0, 0).
 		Render(lipgloss.JoinHorizontal(lipgloss.Left, keyBindings...))
 	
-	// Combine all elements
-	var content string
-	if errorMsg != "" {
-		content = lipgloss.JoinVertical(lipgloss.Left,
-			header,
-			"",
-			formContent,
-			"",
-			errorMsg,
-			"",
-			footer,
-		)
-	} else {
-		content = lipgloss.JoinVertical(lipgloss.Left,
-			header,
-			"",
-			formContent,
-			"",
-			footer,
-		)
-	}
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		separator,
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, items...),
+		"",
+		hint,
+		"",
+		footer,
+	)
 	
-	// Wrap in a fixed-width box - match main app styling
 	mainBox := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(primaryColor).
 		Width(boxWidth).
-		Padding(0, 2).
+		Padding(1, 2).
 		Render(content)
 	
-	// Center the box
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mainBox)
 }
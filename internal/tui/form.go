@@ -16,15 +16,16 @@ type FormModel struct {
 	inputs         []textinput.Model
 	focused        int
 	err            error
-	host           *models.Host              // If editing, this is the host being edited
+	host           *models.Host             // If editing, this is the host being edited
 	isEditing      bool                     // True if editing existing host
-	validationErrs []models.ValidationError  // Validation errors for current input
+	overlaySource  string                   // Set when editing the local overrides of a read-only host
+	validationErrs []models.ValidationError // Validation errors for current input
 	width          int
 	height         int
 }
 
 func NewFormModel() FormModel {
-	var inputs []textinput.Model = make([]textinput.Model, 7)
+	var inputs []textinput.Model = make([]textinput.Model, 8)
 
 	inputs[0] = textinput.New()
 	inputs[0].Placeholder = "Alias"
@@ -61,6 +62,11 @@ func NewFormModel() FormModel {
 	inputs[6].CharLimit = 50
 	inputs[6].Width = 30
 
+	inputs[7] = textinput.New()
+	inputs[7].Placeholder = "Note (optional)"
+	inputs[7].CharLimit = 100
+	inputs[7].Width = 30
+
 	return FormModel{
 		inputs:  inputs,
 		focused: 0,
@@ -83,10 +89,25 @@ func NewFormModelWithHost(host models.Host) FormModel {
 	if len(host.Tags) > 0 {
 		fm.inputs[6].SetValue(strings.Join(host.Tags, ", "))
 	}
+	fm.inputs[7].SetValue(host.Note)
+
+	return fm
+}
 
+// NewOverlayFormModel edits the local overrides of a host from a read-only
+// source. Alias and hostname belong to the source and can't be changed.
+func NewOverlayFormModel(host models.Host, source string) FormModel {
+	fm := NewFormModelWithHost(host)
+	fm.overlaySource = source
+	fm.focused = 2
 	return fm
 }
 
+// isLocked reports whether input i can't be edited
+func (m FormModel) isLocked(i int) bool {
+	return m.overlaySource != "" && i < 2
+}
+
 func (m FormModel) Init() tea.Cmd {
 	// Focus the first input when form is initialized
 	m.inputs[0].Focus()
@@ -106,7 +127,13 @@ func (m FormModel) Update(msg tea.Msg) (FormModel, tea.Cmd) {
 			if msg.Type == tea.KeyEnter && m.focused == len(m.inputs)-1 {
 				// Validate before submitting
 				host := m.GetHost()
-				validationErrs := host.Validate()
+				// Warnings, such as a missing key file, don't stop the save
+				var validationErrs []models.ValidationError
+				for _, err := range host.Validate() {
+					if !err.IsWarning() {
+						validationErrs = append(validationErrs, err)
+					}
+				}
 				if len(validationErrs) > 0 {
 					m.validationErrs = validationErrs
 					return m, nil
@@ -124,6 +151,10 @@ func (m FormModel) Update(msg tea.Msg) (FormModel, tea.Cmd) {
 			if m.focused < 0 {
 				m.focused = len(m.inputs) - 1
 			}
+			// Going backwards past locked fields wraps to the end
+			if m.isLocked(m.focused) {
+				m.focused = len(m.inputs) - 1
+			}
 		case tea.KeyRight:
 			// Move to corresponding field in right column (add 4 if in left column)
 			if m.focused < 4 {
@@ -135,11 +166,16 @@ func (m FormModel) Update(msg tea.Msg) (FormModel, tea.Cmd) {
 			}
 		case tea.KeyLeft:
 			// Move to corresponding field in left column (subtract 4 if in right column)
-			if m.focused >= 4 {
+			if m.focused >= 4 && !m.isLocked(m.focused-4) {
 				// In right column, move to left column
 				m.focused = m.focused - 4
 			}
 		}
+
+		// Skip locked fields when moving forward
+		for m.isLocked(m.focused) {
+			m.focused++
+		}
 	}
 
 	for i := range m.inputs {
@@ -168,7 +204,9 @@ func (m FormModel) View() string {
 	
 	// Subheading - show different text for edit vs add
 	subheadingText := "Add New Host"
-	if m.isEditing {
+	if m.overlaySource != "" {
+		subheadingText = "Local Overrides (" + m.overlaySource + " host)"
+	} else if m.isEditing {
 		subheadingText = "Edit Host"
 	}
 	subheading := lipgloss.NewStyle().
@@ -197,6 +235,7 @@ func (m FormModel) View() string {
 		{"Identity File", m.inputs[4]},
 		{"Proxy Jump", m.inputs[5]},
 		{"Tags", m.inputs[6]},
+		{"Note", m.inputs[7]},
 	}
 	
 	// Render each field
@@ -215,6 +254,10 @@ func (m FormModel) View() string {
 		
 		// Input
 		inputView := field.input.View()
+		if m.isLocked(i) {
+			labelText = lipgloss.NewStyle().Foreground(dimColor).Bold(true).Render(field.label + ":")
+			inputView = lipgloss.NewStyle().Foreground(dimColor).Render("  " + field.input.Value() + " (from source)")
+		}
 		
 		return lipgloss.JoinVertical(lipgloss.Left,
 			labelText,
@@ -222,7 +265,7 @@ func (m FormModel) View() string {
 		)
 	}
 	
-	// Split into two columns (first 4 fields in left, last 3 in right)
+	// Split into two columns (first 4 fields in left, last 4 in right)
 	const columnWidth = 35
 	
 	var leftColumn []string
@@ -235,7 +278,7 @@ func (m FormModel) View() string {
 		leftColumn = append(leftColumn, "") // spacing
 	}
 	
-	// Right column: Identity File, Proxy Jump, Tags
+	// Right column: Identity File, Proxy Jump, Tags, Note
 	for i := 4; i < len(fields); i++ {
 		fieldView := renderField(i, fields[i])
 		rightColumn = append(rightColumn, lipgloss.NewStyle().Width(columnWidth).Render(fieldView))
@@ -335,6 +378,7 @@ func (m FormModel) GetHost() models.Host {
 		IdentityFile: strings.TrimSpace(m.inputs[4].Value()),
 		ProxyJump:    strings.TrimSpace(m.inputs[5].Value()),
 		Tags:         tags,
+		Note:         strings.TrimSpace(m.inputs[7].Value()),
 		Source:       "manual",
 	}
 }
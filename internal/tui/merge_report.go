@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/pkg/models"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxReportLines limits each section of the conflict report
+const maxReportLines = 8
+
+// shadowedFor returns the conflict entry for alias, if other sources define it too
+func (m Model) shadowedFor(alias string) (config.Shadowed, bool) {
+	for _, s := range m.merge.Shadowed {
+		if s.Alias == alias {
+			return s, true
+		}
+	}
+	return config.Shadowed{}, false
+}
+
+// isDuplicate reports whether another shown host has the same address as host
+func (m Model) isDuplicate(host models.Host) bool {
+	address := config.HostAddress(host)
+	for _, d := range m.merge.Duplicates {
+		if d.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// renderConflictMarkers renders the indicators shown after a host's source
+func (m Model) renderConflictMarkers(host models.Host) string {
+	var markers string
+	// Kept short so the source line doesn't wrap; "!" shows the details
+	if s, ok := m.shadowedFor(host.Alias); ok {
+		markers += lipgloss.NewStyle().Foreground(dimColor).Render(fmt.Sprintf(" +%d", len(s.Hidden)))
+	}
+	if m.isDuplicate(host) {
+		markers += lipgloss.NewStyle().Foreground(warningColor).Render(" dup")
+	}
+	return markers
+}
+
+// renderSourceRef renders a source as its icon and display name
+func renderSourceRef(source string) string {
+	icon, name := sourceLabel(source)
+	return icon + " " + name
+}
+
+// renderMergeReport renders the list of shadowed and duplicate hosts
+func (m Model) renderMergeReport() string {
+	title := lipgloss.NewStyle().
+		Foreground(warningColor).
+		Bold(true).
+		Render("! Host Conflicts")
+
+	dim := lipgloss.NewStyle().Foreground(dimColor)
+	heading := lipgloss.NewStyle().Foreground(primaryColor).Bold(true).MarginTop(1)
+
+	var sections []string
+
+	if len(m.merge.Shadowed) == 0 && len(m.merge.Duplicates) == 0 {
+		sections = append(sections, dim.Italic(true).MarginTop(1).Render("No shadowed or duplicate hosts."))
+	}
+
+	if len(m.merge.Shadowed) > 0 {
+		lines := []string{heading.Render(fmt.Sprintf("Shadowed aliases (%d)", len(m.merge.Shadowed)))}
+		for i, s := range m.merge.Shadowed {
+			if i >= maxReportLines {
+				lines = append(lines, dim.Italic(true).Render(fmt.Sprintf("... and %d more", len(m.merge.Shadowed)-maxReportLines)))
+				break
+			}
+			var hidden []string
+			for _, source := range s.Hidden {
+				hidden = append(hidden, renderSourceRef(source))
+			}
+			verb := "hides"
+			if s.Merged {
+				verb = "merged with"
+			}
+			lines = append(lines, fmt.Sprintf("• %s  %s %s %s",
+				lipgloss.NewStyle().Foreground(textColor).Render(s.Alias),
+				renderSourceRef(s.Winner),
+				dim.Render(verb),
+				strings.Join(hidden, ", ")))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	if len(m.merge.Duplicates) > 0 {
+		lines := []string{heading.Render(fmt.Sprintf("Same address, different aliases (%d)", len(m.merge.Duplicates)))}
+		for i, d := range m.merge.Duplicates {
+			if i >= maxReportLines {
+				lines = append(lines, dim.Italic(true).Render(fmt.Sprintf("... and %d more", len(m.merge.Duplicates)-maxReportLines)))
+				break
+			}
+			var hosts []string
+			for _, host := range d.Hosts {
+				hosts = append(hosts, fmt.Sprintf("%s (%s)", host.Alias, renderSourceRef(host.Source)))
+			}
+			lines = append(lines, fmt.Sprintf("• %s  %s",
+				lipgloss.NewStyle().Foreground(textColor).Render(d.Address),
+				strings.Join(hosts, ", ")))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	hint := dim.MarginTop(1).Render("Set merge.precedence in the config file to choose which source wins.")
+
+	actions := lipgloss.NewStyle().
+		MarginTop(1).
+		Render(keyStyle.Render("esc") + descStyle.Render(" Back"))
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		append(append([]string{title}, sections...), hint, actions)...,
+	)
+
+	dialog := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(warningColor).
+		Padding(1, 3).
+		Width(80).
+		Render(content)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
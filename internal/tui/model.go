@@ -1,10 +1,16 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"sshbuddy/internal/config"
+	"sshbuddy/internal/target"
+	"sshbuddy/internal/watch"
 	"sshbuddy/pkg/models"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,6 +26,9 @@ const (
 	stateConfigError
 	stateConfig
 	stateTermixAuth
+	stateMergeReport
+	stateTransfer
+	stateTargetPicker
 )
 
 type item struct {
@@ -59,7 +68,7 @@ func (i item) Description() string {
 	if port == "" {
 		port = "22"
 	}
-	return fmt.Sprintf("%s@%s:%s", i.host.User, i.host.Hostname, port)
+	return fmt.Sprintf("%s:%s", HostDestination(i.host), port)
 }
 
 func (i item) FilterValue() string { return i.host.Alias + i.host.Hostname }
@@ -69,44 +78,49 @@ type Model struct {
 	form              FormModel
 	configView        ConfigViewModel
 	termixAuth        TermixAuthModel
+	transfer          TransferModel
 	state             sessionState
-	config            *models.Config
-	pingStatus        map[string]bool          // track ping status for each host
-	pinging           map[string]bool          // track which hosts are currently being pinged
-	pingTimes         map[string]string        // track ping times for each host
+	config            *models.Config                 // Config file contents (manual hosts only)
+	hosts             []models.Host                  // Merged hosts from all loaded sources
+	merge             config.MergeResult             // Merged hosts plus shadowed/duplicate report
+	sources           []string                       // Enabled sources, in priority order
+	sourceResults     map[string]config.SourceResult // Latest result per source
+	sourceLoading     map[string]bool                // Sources still loading
+	loadGeneration    int                            // Bumped on reload so stale results are dropped
+	pingStatus        map[string]bool                // track ping status for each host
+	pinging           map[string]bool                // track which hosts are currently being pinged
+	pingTimes         map[string]string              // track ping times for each host
 	width             int
 	height            int
-	selectedHost      *models.Host              // Host to connect to after quitting
+	selectedHost      *models.Host             // Host to connect to after quitting
 	editingIndex      int                      // Index of host being edited (-1 if adding new)
-	deleteConfirmHost *models.Host              // Host pending deletion confirmation
+	editingSource     config.HostSource        // Source of host being edited (nil if adding new)
+	editingOverlay    *models.Host             // Read-only source host whose overrides are being edited
+	deleteConfirmHost *models.Host             // Host pending deletion confirmation
 	deleteConfirmIdx  int                      // Index of host pending deletion
-	configErrors      []models.ValidationError  // Config validation errors
+	deleteConfirmSrc  config.HostSource        // Source of host pending deletion
+	configErrors      []models.ValidationError // Config validation errors
+	watcher           *watch.Watcher           // Watches the config and SSH config files
+	pendingChanges    []string                 // Changed files not reloaded yet, while away from the list
+	lastSession       *SessionEndedMsg         // Most recent session, when returning to the list after sessions
+	lastOpened        *TargetOpenedMsg         // Most recent session opened outside this terminal
+	targetHost        *models.Host             // Host the target picker is connecting to
+	targetChoices     []target.Target          // Targets offered by the picker
+	targetFocus       int                      // Focused target in the picker
 }
 
 func NewModel() Model {
-	cfg, err := config.LoadConfig()
+	// Only read the config file here; external sources load in the background
+	cfg, err := config.LoadConfigRaw()
 	var validationErrors []models.ValidationError
-	var needsTermixAuth bool
 	
 	if err != nil {
-		// Check if this is a Termix auth error
-		if strings.Contains(err.Error(), "authentication required") {
-			needsTermixAuth = true
-			cfg = &models.Config{Hosts: []models.Host{}}
-		} else {
-			// Convert error to validation error for display
-			validationErrors = []models.ValidationError{
-				{
-					Field:   "Config",
-					Message: err.Error(),
-					Index:   -1,
-				},
-			}
-			cfg = &models.Config{Hosts: []models.Host{}}
-		}
-	} else {
-		// Validate config
-		validationErrors = cfg.Validate()
+		// Convert error to validation error for display
+		validationErrors = configLoadErrors(err)
+		cfg = &models.Config{Hosts: []models.Host{}}
+	} else if errs := config.Validate(cfg); models.HasErrors(errs) {
+		// Warnings alone don't stop the list; they show above it instead
+		validationErrors = errs
 	}
 	
 	// Apply saved theme or default to purple
@@ -116,11 +130,6 @@ func NewModel() Model {
 	}
 	ApplyTheme(themeName)
 	
-	items := []list.Item{}
-	for _, h := range cfg.Hosts {
-		items = append(items, item{host: h, status: "⚪"})
-	}
-
 	// Custom delegate with original styling
 	delegate := list.NewDefaultDelegate()
 	delegate.SetHeight(3) // Three lines per item (title + description + tags)
@@ -149,7 +158,7 @@ func NewModel() Model {
 		Foreground(dimColor).
 		Padding(0, 0, 0, 2)
 
-	l := list.New(items, delegate, 0, 0)
+	l := list.New([]list.Item{}, delegate, 0, 0)
 	l.Title = ""
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(true)
@@ -160,7 +169,7 @@ func NewModel() Model {
 		list:         l,
 		form:         NewFormModel(),
 		configView:   NewConfigViewModel(),
-		termixAuth:   NewTermixAuthModel(),
+		termixAuth:   NewTermixAuthModel(""),
 		state:        stateList,
 		config:       cfg,
 		pingStatus:   make(map[string]bool),
@@ -170,11 +179,16 @@ func NewModel() Model {
 		configErrors: validationErrors,
 	}
 	
-	// If Termix auth is needed, show auth form
-	if needsTermixAuth {
-		m.state = stateTermixAuth
-	} else if len(validationErrors) > 0 {
-		// If there are validation errors, show error state
+	// Show manual hosts right away; other sources are loaded by Init
+	m.prepareLoading()
+	
+	// Pick up edits made outside sshbuddy; without a watcher, restart to see them
+	if w, err := watch.New(watchedFiles(cfg), watch.DefaultDelay); err == nil {
+		m.watcher = w
+	}
+	
+	// If there are validation errors, show error state
+	if len(validationErrors) > 0 {
 		m.state = stateConfigError
 	}
 	
@@ -183,14 +197,28 @@ func NewModel() Model {
 
 func (m Model) Init() tea.Cmd {
 	// Mark all hosts as pinging on startup
-	for _, h := range m.config.Hosts {
+	for _, h := range m.hosts {
 		key := GetHostKey(h)
 		m.pinging[key] = true
 	}
-	return StartPingAll(m.config.Hosts)
+	return tea.Batch(StartPingAll(m.hosts), m.loadCmds(), waitForChanges(m.watcher))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	m = next.(Model)
+	
+	// Reload files that changed on disk, but not under a form or picker that
+	// may be holding indexes into the current hosts
+	if len(m.pendingChanges) > 0 && m.state == stateList {
+		// applyFileChanges changes m, so call it before m is returned
+		fileCmd := m.applyFileChanges()
+		return m, tea.Batch(cmd, fileCmd)
+	}
+	return m, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -208,6 +236,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Only process shortcuts when NOT in search mode
 			if !isSearching {
 				switch msg.String() {
+				case "!":
+					// Show shadowed and duplicate hosts
+					m.state = stateMergeReport
+					return m, nil
+				case "x":
+					// Export or import hosts
+					var visible []models.Host
+					for _, listItem := range m.list.VisibleItems() {
+						if i, ok := listItem.(item); ok {
+							visible = append(visible, i.host)
+						}
+					}
+					m.state = stateTransfer
+					m.transfer = NewTransferModel(m.hosts, visible)
+					m.transfer.width = m.width
+					m.transfer.height = m.height
+					return m, m.transfer.Init()
 				case "s":
 					// Open settings/configuration
 					m.state = stateConfig
@@ -220,23 +265,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.form = NewFormModel() // Reset form
 					m.form.width = m.width
 					m.form.height = m.height
-					m.editingIndex = -1     // -1 means adding new
+					m.editingIndex = -1 // -1 means adding new
+					m.editingSource = nil
+					m.editingOverlay = nil
 					return m, m.form.Init()
 				case "p":
 					// Ping all servers - mark all as pinging
-					for _, h := range m.config.Hosts {
+					for _, h := range m.hosts {
 						key := GetHostKey(h)
 						m.pinging[key] = true
 					}
 					m.refreshList()
-					return m, StartPingAll(m.config.Hosts)
+					return m, StartPingAll(m.hosts)
 				case "enter":
-					// Connect to selected host
+					// Connect to selected host, where sessions open by default
 					if selectedItem, ok := m.list.SelectedItem().(item); ok {
-						// Return a command that will execute SSH after quitting
-						return m, func() tea.Msg {
-							return ConnectMsg{Host: selectedItem.host}
-						}
+						return m, m.connect(selectedItem.host, target.Default(m.config.Connect))
+					}
+				case "alt+enter":
+					// Choose where to open this session
+					if selectedItem, ok := m.list.SelectedItem().(item); ok {
+						m.openTargetPicker(selectedItem.host)
+						return m, nil
 					}
 				case "up", "k":
 					// Move up in 2-column layout (go back 2 items)
@@ -269,17 +319,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					return m, nil
 				case "e":
-					// Edit selected host (only if from manual/sshbuddy source)
+					// Edit selected host, or its local overrides if the source is read-only
 					if selectedItem, ok := m.list.SelectedItem().(item); ok {
-						if selectedItem.host.Source == "ssh-config" || selectedItem.host.Source == "termix" {
-							// Cannot edit SSH config or Termix hosts
-							return m, nil
+						source, hostIdx := m.writableHostIndex(selectedItem.host)
+						if source == nil {
+							base, ok := m.sourceHost(selectedItem.host)
+							if !ok {
+								return m, nil
+							}
+							host := base
+							if overlay := config.FindOverlay(m.config, base.Source, config.HostID(base)); overlay != nil {
+								host = config.ApplyOverlay(base, *overlay)
+							}
+							m.state = stateForm
+							m.form = NewOverlayFormModel(host, sourceDisplayName(base.Source))
+							m.form.width = m.width
+							m.form.height = m.height
+							m.editingIndex = -1
+							m.editingSource = nil
+							m.editingOverlay = &base
+							return m, m.form.Init()
 						}
+						// Edit the source's own host, not the merged view of it
 						m.state = stateForm
-						m.form = NewFormModelWithHost(selectedItem.host)
+						m.form = NewFormModelWithHost(m.sourceResults[source.Name()].Hosts[hostIdx])
 						m.form.width = m.width
 						m.form.height = m.height
-						m.editingIndex = m.list.Index()
+						m.editingIndex = hostIdx
+						m.editingSource = source
+						m.editingOverlay = nil
 						return m, m.form.Init()
 					}
 				case "c":
@@ -293,21 +361,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.form.width = m.width
 						m.form.height = m.height
 						m.editingIndex = -1 // -1 means adding new (not editing)
+						m.editingSource = nil
+						m.editingOverlay = nil
 						return m, m.form.Init()
 					}
 				case "d", "delete":
-					// Show delete confirmation (only if from manual/sshbuddy source)
+					// Show delete confirmation (only if its source is writable)
 					if selectedItem, ok := m.list.SelectedItem().(item); ok {
-						if selectedItem.host.Source == "ssh-config" || selectedItem.host.Source == "termix" {
-							// Cannot delete SSH config or Termix hosts
+						source, hostIdx := m.writableHostIndex(selectedItem.host)
+						if source == nil {
+							// Cannot delete hosts from read-only sources
 							return m, nil
 						}
-						currentIdx := m.list.Index()
-						if currentIdx >= 0 && currentIdx < len(m.config.Hosts) {
-							m.deleteConfirmHost = &selectedItem.host
-							m.deleteConfirmIdx = currentIdx
-							m.state = stateConfirmDelete
-						}
+						m.deleteConfirmHost = &selectedItem.host
+						m.deleteConfirmIdx = hostIdx
+						m.deleteConfirmSrc = source
+						m.state = stateConfirmDelete
 					}
 					return m, nil
 				}
@@ -318,13 +387,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		} else if m.state == stateConfig {
-			if msg.String() == "esc" {
+			// Esc in a picker or form closes just that; the settings view handles it
+			if msg.String() == "esc" && !m.configView.inSubview() {
 				// Reload config in case it was changed
-				cfg, err := config.LoadConfig()
+				m.state = stateList
+				cfg, err := config.LoadConfigRaw()
 				if err == nil {
 					m.config = cfg
-					m.refreshList()
+					return m, m.reloadSources()
 				}
+				return m, nil
+			}
+		} else if m.state == stateMergeReport {
+			switch msg.String() {
+			case "esc", "!", "q":
+				m.state = stateList
+			}
+			return m, nil
+		} else if m.state == stateTransfer {
+			if msg.String() == "esc" {
 				m.state = stateList
 				return m, nil
 			}
@@ -334,17 +415,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.state = stateList
 				return m, nil
 			}
+		} else if m.state == stateTargetPicker {
+			return m.updateTargetPicker(msg)
 		} else if m.state == stateConfirmDelete {
 			switch msg.String() {
 			case "y", "Y":
 				// Confirm deletion
-				if m.deleteConfirmIdx >= 0 && m.deleteConfirmIdx < len(m.config.Hosts) {
-					m.config.Hosts = append(m.config.Hosts[:m.deleteConfirmIdx], m.config.Hosts[m.deleteConfirmIdx+1:]...)
-					config.SaveConfig(m.config)
-					m.refreshList()
+				if m.deleteConfirmSrc != nil {
+					if err := m.deleteHost(m.deleteConfirmSrc, m.deleteConfirmIdx); err != nil {
+						m.config.Warnings = append(m.config.Warnings, "Delete failed: "+err.Error())
+					}
 					// Adjust selection if needed
-					if m.deleteConfirmIdx >= len(m.config.Hosts) && len(m.config.Hosts) > 0 {
-						m.list.Select(len(m.config.Hosts) - 1)
+					if visible := len(m.list.VisibleItems()); m.list.Index() >= visible && visible > 0 {
+						m.list.Select(visible - 1)
 					}
 				}
 				m.deleteConfirmHost = nil
@@ -378,7 +461,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Fixed width box for 2-column layout
 		const boxWidth = 80
 		listWidth := boxWidth - 8 // Account for padding and borders
-		listHeight := 20 // Height for scrollable list
+		listHeight := 20          // Height for scrollable list
 		m.list.SetSize(listWidth, listHeight)
 		
 		// Update config view size
@@ -392,6 +475,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update termix auth size
 		m.termixAuth.width = msg.Width
 		m.termixAuth.height = msg.Height
+		
+		// Update transfer size
+		m.transfer.width = msg.Width
+		m.transfer.height = msg.Height
 
 	case PingResultMsg:
 		// Update ping status, time, and clear pinging state
@@ -403,53 +490,93 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case FormSubmittedMsg:
-		if m.editingIndex >= 0 && m.editingIndex < len(m.config.Hosts) {
-			// Editing existing host
-			m.config.Hosts[m.editingIndex] = msg.Host
-		} else {
-			// Adding new host
-			m.config.Hosts = append(m.config.Hosts, msg.Host)
+		if m.editingOverlay != nil {
+			// Local overrides of a read-only host
+			if err := m.saveOverlay(*m.editingOverlay, msg.Host); err != nil {
+				m.config.Warnings = append(m.config.Warnings, "Save failed: "+err.Error())
+			}
+		} else if err := m.saveHost(m.editingSource, m.editingIndex, msg.Host); err != nil {
+			// Editing existing host, or adding a new one when editingSource is nil
+			m.config.Warnings = append(m.config.Warnings, "Save failed: "+err.Error())
 		}
-		config.SaveConfig(m.config)
 		m.state = stateList
 		m.editingIndex = -1
-		m.refreshList()
+		m.editingSource = nil
+		m.editingOverlay = nil
 		// Ping the host
 		return m, PingHost(msg.Host)
 
+	case ImportConfirmedMsg:
+		if err := m.importHosts(msg.Plan, msg.Overwrite); err != nil {
+			m.config.Warnings = append(m.config.Warnings, "Import failed: "+err.Error())
+		}
+		m.state = stateList
+		return m, StartPingAll(m.hosts)
+
+	case FilesChangedMsg:
+		m.pendingChanges = append(m.pendingChanges, msg.Paths...)
+		return m, waitForChanges(m.watcher)
+
 	case ConnectMsg:
+		if m.config.ReturnToList {
+			// Run the session with the TUI suspended; list, selection and filter stay as they are
+			m.lastSession, m.lastOpened = nil, nil
+			return m, RunSession(m.config, msg.Host)
+		}
 		// Store the host and quit the TUI
 		m.selectedHost = &msg.Host
 		return m, tea.Quit
+
+	case SessionEndedMsg:
+		m.lastSession = &msg
+		return m, nil
+
+	case TargetOpenedMsg:
+		m.lastSession, m.lastOpened = nil, &msg
+		return m, nil
 	
 	case TermixAuthSuccessMsg:
-		// Reload config after successful auth
-		cfg, err := config.LoadConfig()
+		// Reload config (with the new token) and all sources after successful auth
+		m.state = stateList
+		cfg, err := config.LoadConfigRaw()
 		if err != nil {
-			// If still auth error, stay in auth state
-			if strings.Contains(err.Error(), "authentication required") {
-				return m, nil
-			}
-			// Other errors - show error state
-			m.configErrors = []models.ValidationError{
-				{
-					Field:   "Config",
-					Message: err.Error(),
-					Index:   -1,
-				},
-			}
+			m.configErrors = configLoadErrors(err)
 			m.state = stateConfigError
 			return m, nil
 		}
 		m.config = cfg
-		m.refreshList()
-		m.state = stateList
-		// Start pinging all hosts
-		for _, h := range m.config.Hosts {
+		return m, m.reloadSources()
+	
+	case SourceLoadedMsg:
+		// Ignore results from a load that has since been restarted
+		if msg.Generation != m.loadGeneration {
+			return m, nil
+		}
+		delete(m.sourceLoading, msg.Result.Source)
+		m.sourceResults[msg.Result.Source] = msg.Result
+		m.mergeSources()
+		
+		// Prompt for Termix credentials, but don't interrupt other screens
+		if server, ok := config.TermixServerName(msg.Result.Source); ok && config.IsAuthError(msg.Result.Err) && m.state == stateList {
+			m.termixAuth = NewTermixAuthModel(server)
+			m.termixAuth.width = m.width
+			m.termixAuth.height = m.height
+			m.state = stateTermixAuth
+			return m, m.termixAuth.Init()
+		}
+		
+		// Ping the newly loaded hosts; a refresh only pings hosts not seen before
+		var toPing []models.Host
+		for _, h := range msg.Result.Hosts {
 			key := GetHostKey(h)
+			if _, known := m.pingStatus[key]; msg.Refresh && (known || m.pinging[key]) {
+				continue
+			}
 			m.pinging[key] = true
+			toPing = append(toPing, h)
 		}
-		return m, StartPingAll(m.config.Hosts)
+		m.refreshList()
+		return m, StartPingAll(toPing)
 	}
 
 	if m.state == stateList {
@@ -464,6 +591,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	} else if m.state == stateTermixAuth {
 		m.termixAuth, cmd = m.termixAuth.Update(msg)
 		cmds = append(cmds, cmd)
+	} else if m.state == stateTransfer {
+		m.transfer, cmd = m.transfer.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 	// No update needed for stateConfirmDelete
 
@@ -527,6 +657,19 @@ func (m Model) View() string {
 		return m.renderConfigError()
 	}
 	
+	if m.state == stateMergeReport {
+		// Shadowed and duplicate hosts
+		return m.renderMergeReport()
+	}
+	
+	if m.state == stateTransfer {
+		return m.transfer.View()
+	}
+	
+	if m.state == stateTargetPicker {
+		return m.renderTargetPicker()
+	}
+	
 	// ASCII art header
 	asciiArt := lipgloss.NewStyle().
 		Foreground(primaryColor).
@@ -537,13 +680,12 @@ func (m Model) View() string {
 ╚═╗└─┐├─┤  ╠╩╗│ │ ││ ││└┬┘
 ╚═╝└─┘┴ ┴  ╚═╝└─┘─┴┘─┴┘ ┴`)
 	
-	// Theme indicator
-	theme := GetCurrentTheme()
+	// Per-source status and theme indicator
 	themeIndicator := lipgloss.NewStyle().
 		Foreground(dimColor).
 		Width(boxWidth - 4).
 		Align(lipgloss.Center).
-		Render(fmt.Sprintf("Theme: %s", theme.Name))
+		Render(m.renderSourceStatus())
 	
 	separator := lipgloss.NewStyle().
 		Foreground(dimColor).
@@ -598,11 +740,28 @@ func (m Model) View() string {
 			Render(searchBar)
 	}
 	
+	// Non-blocking warnings from source loading (e.g. offline Termix cache)
+	banner := m.renderWarningBanner(boxWidth - 4)
+	if session := m.renderSessionStatus(boxWidth - 4); session != "" {
+		banner = lipgloss.JoinVertical(lipgloss.Left, session, banner)
+	}
+	
+	// Note of the selected host, if it has one
+	if selectedItem, ok := m.list.SelectedItem().(item); ok && selectedItem.host.Note != "" {
+		note := lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Italic(true).
+			Width(boxWidth - 4).
+			Render("✎ " + selectedItem.host.Note)
+		listView = lipgloss.JoinVertical(lipgloss.Left, listView, note)
+	}
+	
 	// Combine all elements
 	var content string
 	if searchBar != "" {
 		content = lipgloss.JoinVertical(lipgloss.Left,
 			header,
+			banner,
 			searchBar,
 			listView,
 			footer,
@@ -610,7 +769,7 @@ func (m Model) View() string {
 	} else {
 		content = lipgloss.JoinVertical(lipgloss.Left,
 			header,
-			"",
+			banner,
 			listView,
 			footer,
 		)
@@ -629,8 +788,14 @@ func (m Model) View() string {
 }
 
 func (m *Model) refreshList() {
+	// Keep the cursor on the same host when hosts are added or reordered
+	var selected *models.Host
+	if i, ok := m.list.SelectedItem().(item); ok {
+		selected = &i.host
+	}
+	
 	items := []list.Item{}
-	for _, h := range m.config.Hosts {
+	for _, h := range m.hosts {
 		key := GetHostKey(h)
 		status := "⚪" // Default - unknown
 		if pingStatus, exists := m.pingStatus[key]; exists {
@@ -641,6 +806,16 @@ func (m *Model) refreshList() {
 		items = append(items, item{host: h, status: status, pinging: isPinging, pingTime: pingTime})
 	}
 	m.list.SetItems(items)
+	
+	if selected == nil || m.list.FilterState() != list.Unfiltered {
+		return
+	}
+	for idx, listItem := range items {
+		if i := listItem.(item); i.host.Source == selected.Source && i.host.Alias == selected.Alias {
+			m.list.Select(idx)
+			return
+		}
+	}
 }
 
 func (m *Model) renderTwoColumnList() string {
@@ -657,7 +832,7 @@ func (m *Model) renderTwoColumnList() string {
 	const columnWidth = 34 // Each column width
 	const columnGap = 2    // Gap between columns
 	const itemHeight = 3   // Title + Description + Tags
-	const listHeight = 3  // Number of items visible per column
+	const listHeight = 3   // Number of items visible per column
 	
 	var leftColumn, rightColumn []string
 	
@@ -726,13 +901,20 @@ func (m *Model) renderTwoColumnList() string {
 			}
 			
 			// Description line - truncate to fit
-			hostInfo := fmt.Sprintf("%s@%s:%s", itm.host.User, itm.host.Hostname, port)
+			hostInfo := fmt.Sprintf("%s:%s", HostDestination(itm.host), port)
 			if len(hostInfo) > 28 {
 				hostInfo = hostInfo[:25] + "..."
 			}
 			
 			// Source line - render with colors
 			sourceLine := renderSource(itm.host.Source, columnWidth-2, isSelected)
+			if itm.host.Stale {
+				sourceLine += lipgloss.NewStyle().Foreground(warningColor).Render(" (cached)")
+			}
+			if itm.host.Overlaid {
+				sourceLine += lipgloss.NewStyle().Foreground(dimColor).Render(" ✎")
+			}
+			sourceLine += m.renderConflictMarkers(itm.host)
 			
 			var titleLine, descLine string
 			if isSelected {
@@ -794,7 +976,7 @@ func (m *Model) renderTwoColumnList() string {
 	
 	// Render items row-wise: fill left column first, then right column for each row
 	for row := 0; row < listHeight; row++ {
-		leftIdx := startIdx + (row * 2)     // 0, 2, 4, 6...
+		leftIdx := startIdx + (row * 2)      // 0, 2, 4, 6...
 		rightIdx := startIdx + (row * 2) + 1 // 1, 3, 5, 7...
 		
 		leftColumn = append(leftColumn, renderItemAtIndex(leftIdx))
@@ -839,35 +1021,132 @@ func (m Model) GetSelectedHost() *models.Host {
 	return m.selectedHost
 }
 
+// GetConfig returns the config the TUI was showing when it exited
+func (m Model) GetConfig() *models.Config {
+	return m.config
+}
+
+// Close stops watching files; call it once the TUI has exited
+func (m Model) Close() {
+	if m.watcher != nil {
+		m.watcher.Close()
+	}
+}
+
 // renderSource renders the source label with icons
 func renderSource(source string, maxWidth int, isSelected bool) string {
+	icon, displayName := sourceLabel(source)
+	
+	// Use consistent dim color for all sources
+	sourceStyle := lipgloss.NewStyle().Foreground(dimColor)
+	
+	return sourceStyle.Render(icon + " " + displayName)
+}
+
+// sourceDisplayName returns the short display name for a source
+func sourceDisplayName(source string) string {
+	_, displayName := sourceLabel(source)
+	return displayName
+}
+
+// sourceLabel maps source names to icons and display names. Sources with
+// several instances ("kind:instance") are shown by their instance name.
+func sourceLabel(source string) (icon string, displayName string) {
 	if source == "" {
 		source = "sshbuddy"
 	}
 	
-	// Map source names to display names and icons
-	var displayName string
-	var icon string
-	
-	switch source {
-	case "manual", "sshbuddy":
+	kind, instance := config.SplitSourceName(source)
+	switch kind {
+	case config.SourceManual, "sshbuddy":
 		icon = "◆" // Diamond for manual/sshbuddy
 		displayName = "sshbuddy"
-	case "ssh-config":
+	case config.SourceSSHConfig:
 		icon = "■" // Square for config file
 		displayName = "config"
-	case "termix":
+	case config.SourceTermix:
 		icon = "▲" // Triangle for API/cloud
-		displayName = "termix"
+		displayName = instance
+	case config.SourceInventory:
+		icon = "≡" // Lines for inventory files
+		displayName = instance
+	case config.SourceCommand:
+		icon = "»" // Chevron for command output
+		displayName = instance
+	case config.SourceTerraform:
+		icon = "◇" // Hollow diamond for Terraform state
+		displayName = instance
 	default:
 		icon = "○"
 		displayName = source
+		if instance != "" {
+			displayName = instance
+		}
 	}
 	
-	// Use consistent dim color for all sources
-	sourceStyle := lipgloss.NewStyle().Foreground(dimColor)
+	return icon, displayName
+}
+
+// renderWarningBanner renders source loading warnings above the host list.
+// It returns an empty line when there is nothing to report so the layout stays put.
+func (m Model) renderWarningBanner(width int) string {
+	if m.config == nil || len(m.config.Warnings) == 0 {
+		return ""
+	}
 	
-	return sourceStyle.Render(icon + " " + displayName)
+	var lines []string
+	for _, warning := range m.config.Warnings {
+		lines = append(lines, "⚠ "+warning)
+	}
+	
+	return lipgloss.NewStyle().
+		Foreground(warningColor).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+}
+
+// renderSessionStatus renders how the last session ended, or where it was
+// opened if that was outside this terminal. It is empty if no session has run
+// from the TUI.
+func (m Model) renderSessionStatus(width int) string {
+	if opened := m.lastOpened; opened != nil {
+		line := fmt.Sprintf("↗ Opened %s in %s", opened.Host.Alias, strings.ToLower(target.Label(opened.Target)))
+		color := accentColor
+		if opened.Err != nil {
+			line = fmt.Sprintf("✗ %s: %v", opened.Host.Alias, opened.Err)
+			color = errorColor
+		}
+		return lipgloss.NewStyle().
+			Foreground(color).
+			Width(width).
+			Render(line)
+	}
+	
+	session := m.lastSession
+	if session == nil {
+		return ""
+	}
+	
+	duration := session.Duration.Round(time.Second)
+	// Only ssh's own status is unwrapped; a failed hook's status is part of its message
+	exitErr, isExit := session.Err.(*exec.ExitError)
+	var line string
+	color := accentColor
+	switch {
+	case session.Err == nil:
+		line = fmt.Sprintf("✓ Session to %s ended after %s", session.Host.Alias, duration)
+	case isExit:
+		line = fmt.Sprintf("✗ Session to %s ended with exit status %d after %s", session.Host.Alias, exitErr.ExitCode(), duration)
+		color = errorColor
+	default:
+		line = fmt.Sprintf("✗ %s: %v", session.Host.Alias, session.Err)
+		color = errorColor
+	}
+	
+	return lipgloss.NewStyle().
+		Foreground(color).
+		Width(width).
+		Render(line)
 }
 
 // renderDeleteConfirmation renders the delete confirmation dialog
@@ -889,7 +1168,7 @@ func (m Model) renderDeleteConfirmation() string {
 		Foreground(textColor).
 		MarginTop(1).
 		MarginBottom(1).
-		Render(fmt.Sprintf("Alias: %s\nHost: %s@%s", host.Alias, host.User, host.Hostname))
+		Render(fmt.Sprintf("Alias: %s\nHost: %s", host.Alias, HostDestination(*host)))
 	
 	// Confirmation message
 	confirmMsg := lipgloss.NewStyle().
@@ -910,7 +1189,7 @@ func (m Model) renderDeleteConfirmation() string {
 	
 	actions := lipgloss.NewStyle().
 		MarginTop(1).
-		Render(yesButton + descStyle.Render(" Yes  ") + noButton + descStyle.Render(" No  ") + 
+		Render(yesButton + descStyle.Render(" Yes  ") + noButton + descStyle.Render(" No  ") +
 			keyStyle.Render("esc") + descStyle.Render(" Cancel"))
 	
 	// Combine all elements
@@ -946,19 +1225,25 @@ func (m Model) renderConfigError() string {
 	if len(m.configErrors) > 0 {
 		// Check if error is from termix by looking at the error message
 		firstError := m.configErrors[0].Error()
-		if strings.Contains(firstError, "termix") {
+		if strings.Contains(strings.ToLower(firstError), "termix") {
 			errorSource = "Termix"
-		} else if strings.Contains(firstError, "Config:") {
-			errorSource = "configuration"
-		} else {
-			errorSource = "sshbuddy.json"
 		}
 	}
 	
+	warningCount := 0
+	for _, err := range m.configErrors {
+		if err.IsWarning() {
+			warningCount++
+		}
+	}
+	summary := fmt.Sprintf("Found %d error(s) in %s:", len(m.configErrors)-warningCount, errorSource)
+	if warningCount > 0 {
+		summary = fmt.Sprintf("Found %d error(s) and %d warning(s) in %s:", len(m.configErrors)-warningCount, warningCount, errorSource)
+	}
 	errorCount := lipgloss.NewStyle().
 		Foreground(mutedColor).
 		MarginTop(1).
-		Render(fmt.Sprintf("Found %d error(s) in %s:", len(m.configErrors), errorSource))
+		Render(summary)
 	
 	// List errors (limit to first 10)
 	var errorLines []string
@@ -973,8 +1258,12 @@ func (m Model) renderConfigError() string {
 			break
 		}
 		
+		color := errorColor
+		if err.IsWarning() {
+			color = warningColor
+		}
 		errorLine := lipgloss.NewStyle().
-			Foreground(errorColor).
+			Foreground(color).
 			Render(fmt.Sprintf("• %s", err.Error()))
 		errorLines = append(errorLines, errorLine)
 	}
@@ -1002,7 +1291,7 @@ func (m Model) renderConfigError() string {
 	
 	actions := lipgloss.NewStyle().
 		MarginTop(1).
-		Render(ignoreButton + descStyle.Render(" Ignore & Continue  ") + 
+		Render(ignoreButton + descStyle.Render(" Ignore & Continue  ") +
 			quitButton + descStyle.Render(" Quit"))
 	
 	// Combine all elements
@@ -1026,3 +1315,22 @@ func (m Model) renderConfigError() string {
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
 }
 
+// configLoadErrors turns an error loading the config into validation errors
+// for the error screen, keeping the position of parse errors
+func configLoadErrors(err error) []models.ValidationError {
+	var fileErr *config.FileError
+	if errors.As(err, &fileErr) {
+		return []models.ValidationError{{
+			Field:   filepath.Base(fileErr.Path),
+			Message: fileErr.Err.Error(),
+			Index:   -1,
+			Line:    fileErr.Line,
+			Column:  fileErr.Column,
+		}}
+	}
+	return []models.ValidationError{{
+		Field:   "Config",
+		Message: err.Error(),
+		Index:   -1,
+	}}
+}
@@ -0,0 +1,305 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/internal/transfer"
+	"sshbuddy/pkg/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SourceLoadedMsg carries the result of loading one host source
+type SourceLoadedMsg struct {
+	Generation int  // Load generation the result belongs to
+	Refresh    bool // Reloaded after a file change; pinged hosts keep their status
+	Result     config.SourceResult
+}
+
+// loadSourceCmd loads a single source in the background
+func loadSourceCmd(cfg models.Config, source string, generation int, refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		return SourceLoadedMsg{
+			Generation: generation,
+			Refresh:    refresh,
+			Result:     config.LoadSource(&cfg, source),
+		}
+	}
+}
+
+// prepareLoading resets per-source state for a fresh load. Manual hosts are
+// read straight from the config file, so they are available immediately.
+func (m *Model) prepareLoading() {
+	m.loadGeneration++
+	m.sources = config.EnabledSources(m.config)
+	m.sourceResults = make(map[string]config.SourceResult)
+	m.sourceLoading = make(map[string]bool)
+
+	for _, source := range m.sources {
+		if source == config.SourceManual {
+			m.sourceResults[source] = config.LoadSource(m.config, source)
+			continue
+		}
+		m.sourceLoading[source] = true
+	}
+
+	m.mergeSources()
+}
+
+// loadCmds returns commands loading every source still marked as loading
+func (m Model) loadCmds() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, source := range m.sources {
+		if m.sourceLoading[source] {
+			cmds = append(cmds, loadSourceCmd(*m.config, source, m.loadGeneration, false))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// refreshSources reloads the named sources, or all of them if names is nil,
+// after m.config changed. Hosts stay listed until their source reloads, and
+// only hosts that were never pinged are pinged.
+func (m *Model) refreshSources(names []string) tea.Cmd {
+	m.loadGeneration++
+	m.sources = config.EnabledSources(m.config)
+	m.sourceLoading = make(map[string]bool)
+
+	// Drop sources that were turned off
+	for name := range m.sourceResults {
+		if !slices.Contains(m.sources, name) {
+			delete(m.sourceResults, name)
+		}
+	}
+
+	var cmds []tea.Cmd
+	for _, source := range m.sources {
+		if source == config.SourceManual {
+			m.sourceResults[source] = config.LoadSource(m.config, source)
+			continue
+		}
+		// Sources still loading lost their load to the new generation
+		_, loaded := m.sourceResults[source]
+		if loaded && names != nil && !slices.Contains(names, source) {
+			continue
+		}
+		m.sourceLoading[source] = true
+		cmds = append(cmds, loadSourceCmd(*m.config, source, m.loadGeneration, true))
+	}
+	m.mergeSources()
+
+	var unpinged []models.Host
+	for _, h := range m.hosts {
+		key := GetHostKey(h)
+		if _, known := m.pingStatus[key]; !known && !m.pinging[key] {
+			m.pinging[key] = true
+			unpinged = append(unpinged, h)
+		}
+	}
+	m.refreshList()
+	return tea.Batch(append(cmds, StartPingAll(unpinged))...)
+}
+
+// reloadSources re-reads every enabled source and pings the result
+func (m *Model) reloadSources() tea.Cmd {
+	m.prepareLoading()
+	for _, h := range m.hosts {
+		m.pinging[GetHostKey(h)] = true
+	}
+	m.refreshList()
+	return tea.Batch(StartPingAll(m.hosts), m.loadCmds())
+}
+
+// mergeSources rebuilds the visible host list from the loaded sources
+func (m *Model) mergeSources() {
+	var results []config.SourceResult
+	var warnings []string
+	for _, source := range m.sources {
+		result, ok := m.sourceResults[source]
+		if !ok {
+			continue
+		}
+		results = append(results, result)
+		warnings = append(warnings, result.Warnings...)
+		if result.Err != nil && !config.IsAuthError(result.Err) {
+			// Only the first line; the full error is in the debug log
+			firstLine := strings.SplitN(result.Err.Error(), "\n", 2)[0]
+			if name := sourceDisplayName(source); !strings.HasPrefix(strings.ToLower(firstLine), name) {
+				firstLine = name + ": " + firstLine
+			}
+			warnings = append(warnings, firstLine)
+		}
+	}
+
+	// Config warnings don't stop anything loading, so they join the source warnings
+	warnings = append(warnings, configWarnings(m.config)...)
+
+	m.merge = config.MergeSources(m.config, results)
+	m.hosts = m.merge.Hosts
+	m.config.Warnings = warnings
+	m.refreshList()
+}
+
+// maxConfigWarnings caps the config warnings listed above the hosts
+const maxConfigWarnings = 3
+
+// configWarnings returns the warnings config.Validate finds in cfg
+func configWarnings(cfg *models.Config) []string {
+	var warnings []string
+	count := 0
+	for _, err := range config.Validate(cfg) {
+		if !err.IsWarning() {
+			continue
+		}
+		count++
+		if count <= maxConfigWarnings {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	if count > maxConfigWarnings {
+		warnings = append(warnings, fmt.Sprintf("%d more config warnings", count-maxConfigWarnings))
+	}
+	return warnings
+}
+
+// syncSource reloads a source after its hosts were changed in place
+func (m *Model) syncSource(name string) {
+	if _, ok := m.sourceResults[name]; ok {
+		m.sourceResults[name] = config.LoadSource(m.config, name)
+	}
+	m.mergeSources()
+}
+
+// writableHostIndex returns the source holding host and the host's index
+// among that source's hosts, or nil and -1 if the host can't be changed
+func (m Model) writableHostIndex(host models.Host) (config.HostSource, int) {
+	source := config.FindSource(m.config, host.Source)
+	if source == nil || !source.Writable() {
+		return nil, -1
+	}
+	for i, h := range m.sourceResults[source.Name()].Hosts {
+		if h.Alias == host.Alias {
+			return source, i
+		}
+	}
+	return nil, -1
+}
+
+// sourceHost returns host as its source provides it, before overlays and merging
+func (m Model) sourceHost(host models.Host) (models.Host, bool) {
+	for _, h := range m.sourceResults[host.Source].Hosts {
+		if h.Alias == host.Alias {
+			return h, true
+		}
+	}
+	return models.Host{}, false
+}
+
+// saveOverlay stores edited as local overrides of base, a read-only host
+func (m *Model) saveOverlay(base, edited models.Host) error {
+	var overlays []models.HostOverlay
+	err := config.UpdateConfig(func(cfg *models.Config) error {
+		if err := config.SetOverlay(cfg, base, edited); err != nil {
+			return err
+		}
+		overlays = cfg.Overlays
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.config.Overlays = overlays
+	m.mergeSources()
+	return nil
+}
+
+// saveHost replaces the host at index in source, or adds it when index is -1.
+// New hosts without a writable source go to the manual source.
+func (m *Model) saveHost(source config.HostSource, index int, host models.Host) error {
+	if source == nil {
+		source = config.FindSource(m.config, config.SourceManual)
+	}
+	hosts := append([]models.Host(nil), m.sourceHosts(source)...)
+	if index >= 0 && index < len(hosts) {
+		hosts[index] = host
+	} else {
+		hosts = append(hosts, host)
+	}
+	if err := source.Save(hosts); err != nil {
+		return err
+	}
+	m.syncSource(source.Name())
+	return nil
+}
+
+// importHosts adds the hosts of an import plan to the manual source
+func (m *Model) importHosts(plan transfer.ImportPlan, overwrite bool) error {
+	source := config.FindSource(m.config, config.SourceManual)
+	if err := source.Save(plan.Apply(m.sourceHosts(source), overwrite)); err != nil {
+		return err
+	}
+	m.syncSource(source.Name())
+	return nil
+}
+
+// deleteHost removes the host at index from source
+func (m *Model) deleteHost(source config.HostSource, index int) error {
+	hosts := append([]models.Host(nil), m.sourceHosts(source)...)
+	if index < 0 || index >= len(hosts) {
+		return nil
+	}
+	hosts = append(hosts[:index], hosts[index+1:]...)
+	if err := source.Save(hosts); err != nil {
+		return err
+	}
+	m.syncSource(source.Name())
+	return nil
+}
+
+// sourceHosts returns the hosts of source, loading it if it isn't shown
+func (m Model) sourceHosts(source config.HostSource) []models.Host {
+	if result, ok := m.sourceResults[source.Name()]; ok {
+		return result.Hosts
+	}
+	return source.Load().Hosts
+}
+
+// renderSourceStatus renders the per-source loading/error/count indicators
+func (m Model) renderSourceStatus() string {
+	dim := lipgloss.NewStyle().Foreground(dimColor)
+
+	var parts []string
+	for _, source := range m.sources {
+		icon, name := sourceLabel(source)
+		label := dim.Render(icon + " " + name + " ")
+
+		var status string
+		result, loaded := m.sourceResults[source]
+		switch {
+		case m.sourceLoading[source]:
+			status = statusPingingStyle.Render("…")
+		case !loaded:
+			status = dim.Render("-")
+		case config.IsAuthError(result.Err):
+			status = lipgloss.NewStyle().Foreground(warningColor).Render("login")
+		case result.Err != nil:
+			status = lipgloss.NewStyle().Foreground(errorColor).Render("✗")
+		case len(result.Warnings) > 0:
+			status = lipgloss.NewStyle().Foreground(warningColor).Render(fmt.Sprintf("%d", len(result.Hosts)))
+		default:
+			status = dim.Render(fmt.Sprintf("%d", len(result.Hosts)))
+		}
+		parts = append(parts, label+status)
+	}
+
+	if conflicts := m.merge.Conflicts(); conflicts > 0 {
+		parts = append(parts, lipgloss.NewStyle().Foreground(warningColor).Render(fmt.Sprintf("! %d conflicts", conflicts)))
+	}
+
+	parts = append(parts, dim.Render(fmt.Sprintf("Theme: %s", GetCurrentTheme().Name)))
+	return strings.Join(parts, dim.Render("  ·  "))
+}
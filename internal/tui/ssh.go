@@ -1,11 +1,17 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sshbuddy/internal/askpass"
+	"sshbuddy/internal/hooks"
 	"sshbuddy/pkg/models"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -21,6 +27,83 @@ type ConnectMsg struct {
 	Host models.Host
 }
 
+// SessionEndedMsg reports the end of a session run from the TUI
+type SessionEndedMsg struct {
+	Host     models.Host
+	Err      error // As returned by Connect
+	Duration time.Duration
+}
+
+// sessionCommand runs Connect while the TUI is suspended. It implements
+// tea.ExecCommand; Connect already attaches ssh and hooks to the terminal,
+// so the streams the program hands over are not needed.
+type sessionCommand struct {
+	cfg      *models.Config
+	host     models.Host
+	duration time.Duration
+}
+
+func (c *sessionCommand) Run() error {
+	fmt.Printf("Connecting to %s...\n", HostDestination(c.host))
+	start := time.Now()
+	err := Connect(c.cfg, c.host)
+	c.duration = time.Since(start)
+	return err
+}
+
+func (c *sessionCommand) SetStdin(io.Reader)  {}
+func (c *sessionCommand) SetStdout(io.Writer) {}
+func (c *sessionCommand) SetStderr(io.Writer) {}
+
+// RunSession suspends the TUI, connects to host and resumes the TUI when
+// the session ends, with a SessionEndedMsg
+func RunSession(cfg *models.Config, host models.Host) tea.Cmd {
+	session := &sessionCommand{cfg: cfg, host: host}
+	return tea.Exec(session, func(err error) tea.Msg {
+		return SessionEndedMsg{Host: host, Err: err, Duration: session.duration}
+	})
+}
+
+// HostDestination returns user@hostname, or the hostname if there is no user
+func HostDestination(host models.Host) string {
+	if host.User == "" {
+		return host.Hostname
+	}
+	return host.User + "@" + host.Hostname
+}
+
+// Connect runs the host's pre-connect hooks, an SSH session in the
+// foreground, then its post-disconnect hooks. A failing pre-connect hook
+// cancels the connection. Post-disconnect hooks run even if ssh fails; their
+// own failures are reported on stderr, as the session is already over.
+func Connect(cfg *models.Config, host models.Host) error {
+	var hookConfig models.HooksConfig
+	if cfg != nil {
+		hookConfig = cfg.Hooks
+	}
+
+	pre := hooks.Commands(hookConfig, host, hooks.PreConnect)
+	if err := hooks.Run(pre, hooks.Env(host, hooks.PreConnect, hooks.Result{})); err != nil {
+		return fmt.Errorf("connection cancelled: pre-connect %w", err)
+	}
+
+	start := time.Now()
+	err := ExecuteSSH(host)
+
+	result := hooks.Result{Duration: time.Since(start)}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		result.ExitCode = -1
+	}
+	post := hooks.Commands(hookConfig, host, hooks.PostDisconnect)
+	if hookErr := hooks.Run(post, hooks.Env(host, hooks.PostDisconnect, result)); hookErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: post-disconnect %v\n", hookErr)
+	}
+	return err
+}
+
 // ExecuteSSH executes SSH connection in the foreground
 func ExecuteSSH(host models.Host) error {
 	port := host.Port
@@ -33,9 +116,23 @@ func ExecuteSSH(host models.Host) error {
 	// Add port
 	args = append(args, "-p", port)
 	
-	// Add identity file if specified
+	// Add identity file if specified; keyFile is as ssh names it in prompts
+	var keyFile string
 	if host.IdentityFile != "" {
 		args = append(args, "-i", host.IdentityFile)
+		keyFile = host.IdentityFile
+		if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(keyFile, "~/") {
+			keyFile = home + keyFile[1:]
+		}
+	} else if host.Key != "" {
+		// Termix keys come as content; ssh needs a private file for the session
+		sessionKey, cleanup, err := writeSessionKey(host.Key)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		args = append(args, "-i", sessionKey, "-o", "IdentitiesOnly=yes")
+		keyFile = sessionKey
 	}
 	
 	// Add proxy jump if specified
@@ -43,8 +140,13 @@ func ExecuteSSH(host models.Host) error {
 		args = append(args, "-J", host.ProxyJump)
 	}
 	
-	// Add host
-	args = append(args, fmt.Sprintf("%s@%s", host.User, host.Hostname))
+	// Add local port forwards
+	for _, forward := range host.LocalForwards {
+		args = append(args, "-L", forward)
+	}
+	
+	// Add host (without a user, ssh picks one from ~/.ssh/config or the local login)
+	args = append(args, HostDestination(host))
 
 	cmd := exec.Command("ssh", args...)
 	
@@ -53,10 +155,55 @@ func ExecuteSSH(host models.Host) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
+	// Serve stored passwords through the askpass helper so they never
+	// appear in argv or on disk
+	if host.Password != "" || host.KeyPassphrase != "" {
+		server, err := askpass.Start(askpass.Secrets{
+			Password:      host.Password,
+			KeyPassphrase: host.KeyPassphrase,
+			User:          host.User,
+			Hostname:      host.Hostname,
+			KeyFile:       keyFile,
+			ProxyJump:     host.ProxyJump != "",
+		})
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+
+		env, err := server.Env()
+		if err != nil {
+			return err
+		}
+		cmd.Env = append(os.Environ(), env...)
+	}
+
 	// Run SSH in foreground and wait for it to complete
 	return cmd.Run()
 }
 
+// writeSessionKey writes private key content to a 0600 file in a private
+// temp directory and returns a cleanup func that removes it
+func writeSessionKey(key string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "sshbuddy-key-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create key dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	if !strings.HasSuffix(key, "\n") {
+		key += "\n"
+	}
+
+	path := filepath.Join(dir, "id")
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write session key: %w", err)
+	}
+
+	return path, cleanup, nil
+}
+
 // PingHost checks if a host is reachable using a simple ping
 func PingHost(host models.Host) tea.Cmd {
 	return func() tea.Msg {
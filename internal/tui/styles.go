@@ -91,13 +91,14 @@ var currentTheme = themes["purple"]
 
 var (
 	// Minimal color palette
-	primaryColor   = currentTheme.Primary
-	accentColor    = currentTheme.Accent
-	errorColor     = currentTheme.Error
-	textColor      = currentTheme.Text
-	mutedColor     = currentTheme.Muted
-	dimColor       = currentTheme.Dim
-	borderColor    = currentTheme.Border
+	primaryColor = currentTheme.Primary
+	accentColor  = currentTheme.Accent
+	errorColor   = currentTheme.Error
+	textColor    = currentTheme.Text
+	mutedColor   = currentTheme.Muted
+	dimColor     = currentTheme.Dim
+	borderColor  = currentTheme.Border
+	warningColor = currentTheme.PingingWarn
 
 	// Clean title style
 	titleStyle = lipgloss.NewStyle().
@@ -178,6 +179,7 @@ func ApplyTheme(themeName string) {
 	mutedColor = theme.Muted
 	dimColor = theme.Dim
 	borderColor = theme.Border
+	warningColor = theme.PingingWarn
 	
 	// Update all styles
 	titleStyle = titleStyle.Foreground(primaryColor)
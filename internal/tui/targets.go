@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"sshbuddy/internal/target"
+	"sshbuddy/pkg/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TargetOpenedMsg reports a session opened outside this terminal
+type TargetOpenedMsg struct {
+	Host   models.Host
+	Target string
+	Err    error
+}
+
+// OpenInTarget opens a session with host in a new tmux window or pane,
+// screen window or terminal window. The TUI stays open.
+func OpenInTarget(cfg *models.Config, name string, host models.Host) tea.Cmd {
+	return func() tea.Msg {
+		return TargetOpenedMsg{Host: host, Target: name, Err: target.Open(cfg.Connect, name, host)}
+	}
+}
+
+// connect returns the command that connects to host in the target called name
+func (m Model) connect(host models.Host, name string) tea.Cmd {
+	if name == models.TargetTerminal {
+		return func() tea.Msg {
+			return ConnectMsg{Host: host}
+		}
+	}
+	return OpenInTarget(m.config, name, host)
+}
+
+// openTargetPicker shows the targets available for connecting to host, with
+// the default one focused
+func (m *Model) openTargetPicker(host models.Host) {
+	m.targetHost = &host
+	m.targetChoices = target.Available(m.config.Connect)
+	m.targetFocus = 0
+	defaultTarget := target.Default(m.config.Connect)
+	for i, choice := range m.targetChoices {
+		if choice.Name == defaultTarget {
+			m.targetFocus = i
+		}
+	}
+	m.state = stateTargetPicker
+}
+
+// updateTargetPicker handles keys in the target picker
+func (m Model) updateTargetPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	choose := func(i int) (tea.Model, tea.Cmd) {
+		host := *m.targetHost
+		m.targetHost = nil
+		m.state = stateList
+		return m, m.connect(host, m.targetChoices[i].Name)
+	}
+
+	switch key := msg.String(); key {
+	case "up", "k":
+		if m.targetFocus > 0 {
+			m.targetFocus--
+		}
+	case "down", "j":
+		if m.targetFocus < len(m.targetChoices)-1 {
+			m.targetFocus++
+		}
+	case "enter":
+		return choose(m.targetFocus)
+	case "esc", "q":
+		m.targetHost = nil
+		m.state = stateList
+	default:
+		// Number keys pick a target directly
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(m.targetChoices) {
+			return choose(int(key[0] - '1'))
+		}
+	}
+	return m, nil
+}
+
+// renderTargetPicker renders the choice of where to open the session
+func (m Model) renderTargetPicker() string {
+	if m.targetHost == nil {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Render("Connect to " + m.targetHost.Alias)
+
+	var lines []string
+	for i, choice := range m.targetChoices {
+		line := fmt.Sprintf("%d  %s", i+1, choice.Label)
+		if i == m.targetFocus {
+			lines = append(lines, lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Bold(true).
+				BorderLeft(true).
+				BorderStyle(lipgloss.NormalBorder()).
+				BorderForeground(primaryColor).
+				Padding(0, 0, 0, 1).
+				Render(line))
+		} else {
+			lines = append(lines, lipgloss.NewStyle().
+				Foreground(textColor).
+				Padding(0, 0, 0, 2).
+				Render(line))
+		}
+	}
+	choices := lipgloss.NewStyle().
+		MarginTop(1).
+		MarginBottom(1).
+		Render(strings.Join(lines, "\n"))
+
+	actions := keyStyle.Render("↵") + descStyle.Render(" Connect  ") +
+		keyStyle.Render("1-9") + descStyle.Render(" Pick  ") +
+		keyStyle.Render("esc") + descStyle.Render(" Cancel")
+
+	dialog := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Padding(1, 4).
+		Width(50).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, choices, actions))
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
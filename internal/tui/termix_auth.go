@@ -11,6 +11,7 @@ import (
 )
 
 type TermixAuthModel struct {
+	server    string // Name of the Termix server to log in to
 	inputs    []textinput.Model
 	focused   int
 	err       error
@@ -19,7 +20,7 @@ type TermixAuthModel struct {
 	authError string
 }
 
-func NewTermixAuthModel() TermixAuthModel {
+func NewTermixAuthModel(server string) TermixAuthModel {
 	var inputs []textinput.Model = make([]textinput.Model, 2)
 
 	inputs[0] = textinput.New()
@@ -36,6 +37,7 @@ func NewTermixAuthModel() TermixAuthModel {
 	inputs[1].Width = 40
 
 	return TermixAuthModel{
+		server:  server,
 		inputs:  inputs,
 		focused: 0,
 	}
@@ -75,7 +77,7 @@ func (m TermixAuthModel) Update(msg tea.Msg) (TermixAuthModel, tea.Cmd) {
 			}
 			
 			// Attempt authentication
-			err := config.AuthenticateTermix(username, password)
+			err := config.AuthenticateTermix(m.server, username, password)
 			if err != nil {
 				m.authError = fmt.Sprintf("Authentication failed: %v", err)
 				// Clear password field on error
@@ -114,7 +116,7 @@ func (m TermixAuthModel) View() string {
 		Foreground(mutedColor).
 		Width(boxWidth - 4).
 		Align(lipgloss.Center).
-		Render("Your session has expired. Please log in again.")
+		Render(fmt.Sprintf("Your session on %s has expired. Please log in again.", m.server))
 	
 	separator := lipgloss.NewStyle().
 		Foreground(dimColor).
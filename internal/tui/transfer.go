@@ -0,0 +1,346 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sshbuddy/internal/transfer"
+	"sshbuddy/pkg/models"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxPreviewLines limits the hosts listed in the import preview
+const maxPreviewLines = 10
+
+// TransferModel exports the host list to a file or imports hosts from one
+type TransferModel struct {
+	importing bool // Import tab is shown instead of Export
+	format    int  // Index into transfer.Formats
+	allHosts  bool // Export every host instead of the visible ones
+	path      textinput.Model
+	all       []models.Host        // Every merged host
+	visible   []models.Host        // Hosts matching the list filter
+	existing  []models.Host        // Hosts imported ones are compared with
+	plan      *transfer.ImportPlan // Import preview, once a file was read
+	confirm   bool                 // Export file exists; y replaces it
+	status    string
+	err       string
+	width     int
+	height    int
+}
+
+// ImportConfirmedMsg asks the model to add the planned hosts to the manual source
+type ImportConfirmedMsg struct {
+	Plan      transfer.ImportPlan
+	Overwrite bool
+}
+
+func NewTransferModel(all, visible []models.Host) TransferModel {
+	path := textinput.New()
+	path.Placeholder = "File path"
+	path.CharLimit = 200
+	path.Width = 50
+	path.SetValue("~/sshbuddy-hosts.conf")
+	path.Focus()
+
+	return TransferModel{
+		path:     path,
+		all:      all,
+		visible:  visible,
+		existing: all,
+		allHosts: len(visible) == len(all),
+	}
+}
+
+func (m TransferModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m TransferModel) Update(msg tea.Msg) (TransferModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		// The import preview waits for a decision
+		if m.plan != nil {
+			switch key.String() {
+			case "enter", "o":
+				plan := *m.plan
+				overwrite := key.String() == "o"
+				return m, func() tea.Msg { return ImportConfirmedMsg{Plan: plan, Overwrite: overwrite} }
+			case "backspace":
+				m.plan = nil
+			}
+			return m, nil
+		}
+
+		// Any key but y leaves an existing export file alone
+		if m.confirm {
+			m.confirm = false
+			m.err = ""
+			if key.String() == "y" {
+				m.export(true)
+				return m, nil
+			}
+		}
+
+		switch key.Type {
+		case tea.KeyTab, tea.KeyShiftTab:
+			m.importing = !m.importing
+			m.status, m.err = "", ""
+			if m.importing {
+				m.format = formatIndex(transfer.DetectFormat(m.path.Value()))
+			}
+			return m, nil
+		case tea.KeyLeft, tea.KeyRight:
+			step := 1
+			if key.Type == tea.KeyLeft {
+				step = len(transfer.Formats) - 1
+			}
+			m.format = (m.format + step) % len(transfer.Formats)
+			if !m.importing {
+				m.path.SetValue(withExtension(m.path.Value(), transfer.Formats[m.format]))
+				m.path.CursorEnd()
+			}
+			return m, nil
+		case tea.KeyUp, tea.KeyDown:
+			if !m.importing {
+				m.allHosts = !m.allHosts
+			}
+			return m, nil
+		case tea.KeyEnter:
+			if m.importing {
+				m.readImport()
+			} else {
+				m.export(false)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.path, cmd = m.path.Update(msg)
+	return m, cmd
+}
+
+// export writes the chosen hosts to the file at path. An existing file is
+// only replaced when overwrite is set; otherwise the user is asked first.
+func (m *TransferModel) export(overwrite bool) {
+	m.status, m.err = "", ""
+	path := strings.TrimSpace(m.path.Value())
+	if path == "" {
+		m.err = "Enter a file path"
+		return
+	}
+	hosts := m.visible
+	if m.allHosts {
+		hosts = m.all
+	}
+	skipped, err := transfer.ExportFile(path, hosts, transfer.Formats[m.format], overwrite)
+	if err != nil {
+		if errors.Is(err, transfer.ErrFileExists) {
+			m.confirm = true
+			m.err = path + " already exists. Press y to replace it"
+			return
+		}
+		m.err = "Export failed: " + err.Error()
+		return
+	}
+	m.status = fmt.Sprintf("Exported %d host(s) to %s", len(hosts)-len(skipped), path)
+	if len(skipped) > 0 {
+		m.err = fmt.Sprintf("Skipped %d host(s) with a line break or quote in a value: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+}
+
+// readImport reads the file at path and shows what importing it would do
+func (m *TransferModel) readImport() {
+	m.status, m.err = "", ""
+	path := strings.TrimSpace(m.path.Value())
+	if path == "" || path == "-" {
+		m.err = "Enter a file path"
+		return
+	}
+	hosts, err := transfer.ParseFile(path, transfer.Formats[m.format])
+	if err != nil {
+		m.err = "Import failed: " + err.Error()
+		return
+	}
+	if len(hosts) == 0 {
+		m.err = "No hosts found in " + path
+		return
+	}
+	plan := transfer.PlanImport(m.existing, hosts)
+	m.plan = &plan
+}
+
+// formatIndex returns the index of format in transfer.Formats
+func formatIndex(format string) int {
+	for i, f := range transfer.Formats {
+		if f == format {
+			return i
+		}
+	}
+	return 0
+}
+
+// withExtension replaces a known export extension of path with the one for format
+func withExtension(path, format string) string {
+	ext := map[string]string{
+		transfer.FormatSSH:  ".conf",
+		transfer.FormatJSON: ".json",
+		transfer.FormatCSV:  ".csv",
+	}
+	switch filepath.Ext(path) {
+	case ".conf", ".json", ".csv":
+		return strings.TrimSuffix(path, filepath.Ext(path)) + ext[format]
+	}
+	return path
+}
+
+func (m TransferModel) View() string {
+	const boxWidth = 70
+
+	dim := lipgloss.NewStyle().Foreground(dimColor)
+	label := lipgloss.NewStyle().Foreground(textColor).Bold(true)
+	active := lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Underline(true)
+
+	exportTab, importTab := dim.Render("Export"), dim.Render("Import")
+	if m.importing {
+		importTab = active.Render("Import")
+	} else {
+		exportTab = active.Render("Export")
+	}
+	header := lipgloss.JoinVertical(lipgloss.Left,
+		exportTab+"   "+importTab,
+		dim.Render(strings.Repeat("─", boxWidth-4)),
+	)
+
+	var sections []string
+	if m.plan != nil {
+		sections = append(sections, m.renderPreview())
+	} else {
+		var formats []string
+		for i, f := range transfer.Formats {
+			if i == m.format {
+				formats = append(formats, active.Render(f))
+			} else {
+				formats = append(formats, dim.Render(f))
+			}
+		}
+		sections = append(sections, label.Render("Format: ")+strings.Join(formats, "  "))
+
+		if !m.importing {
+			visible := fmt.Sprintf("Shown hosts (%d)", len(m.visible))
+			all := fmt.Sprintf("All hosts (%d)", len(m.all))
+			if m.allHosts {
+				visible, all = dim.Render("○ "+visible), "● "+all
+			} else {
+				visible, all = "● "+visible, dim.Render("○ "+all)
+			}
+			sections = append(sections, label.Render("Hosts:  ")+visible+"  "+all)
+		}
+
+		sections = append(sections, label.Render("File:"), m.path.View())
+		if m.importing {
+			sections = append(sections, dim.Render("Imported hosts are added as sshbuddy hosts."))
+		}
+	}
+
+	if m.err != "" {
+		sections = append(sections, lipgloss.NewStyle().Foreground(errorColor).Render("✗ "+m.err))
+	}
+	if m.status != "" {
+		sections = append(sections, lipgloss.NewStyle().Foreground(primaryColor).Render("✓ "+m.status))
+	}
+
+	var keyBindings []string
+	switch {
+	case m.plan != nil:
+		keyBindings = []string{
+			keyStyle.Render("enter") + descStyle.Render(":import new "),
+			keyStyle.Render("o") + descStyle.Render(":import & overwrite "),
+			keyStyle.Render("backspace") + descStyle.Render(":back "),
+			keyStyle.Render("esc") + descStyle.Render(":cancel"),
+		}
+	case m.confirm:
+		keyBindings = []string{
+			keyStyle.Render("y") + descStyle.Render(":replace file "),
+			keyStyle.Render("any key") + descStyle.Render(":keep it"),
+		}
+	case m.importing:
+		keyBindings = []string{
+			keyStyle.Render("tab") + descStyle.Render(":export "),
+			keyStyle.Render("←→") + descStyle.Render(":format "),
+			keyStyle.Render("enter") + descStyle.Render(":preview "),
+			keyStyle.Render("esc") + descStyle.Render(":close"),
+		}
+	default:
+		keyBindings = []string{
+			keyStyle.Render("tab") + descStyle.Render(":import "),
+			keyStyle.Render("←→") + descStyle.Render(":format "),
+			keyStyle.Render("↑↓") + descStyle.Render(":hosts "),
+			keyStyle.Render("enter") + descStyle.Render(":export "),
+			keyStyle.Render("esc") + descStyle.Render(":close"),
+		}
+	}
+	footer := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), true, false, false, false).
+		BorderForeground(borderColor).
+		Width(boxWidth - 4).
+		Render(lipgloss.JoinHorizontal(lipgloss.Left, keyBindings...))
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		"",
+		strings.Join(sections, "\n\n"),
+		"",
+		footer,
+	)
+
+	mainBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Width(boxWidth).
+		Padding(1, 2).
+		Render(content)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, mainBox)
+}
+
+// renderPreview lists what importing the file would add, skip or replace
+func (m TransferModel) renderPreview() string {
+	dim := lipgloss.NewStyle().Foreground(dimColor)
+	added := lipgloss.NewStyle().Foreground(primaryColor)
+	conflict := lipgloss.NewStyle().Foreground(warningColor)
+
+	summary := fmt.Sprintf("%d to add, %d conflict(s), %d unchanged",
+		m.plan.Count(transfer.ImportNew),
+		m.plan.Count(transfer.ImportConflict),
+		m.plan.Count(transfer.ImportSame),
+	)
+	if invalid := m.plan.Count(transfer.ImportInvalid); invalid > 0 {
+		summary += fmt.Sprintf(", %d invalid", invalid)
+	}
+	lines := []string{lipgloss.NewStyle().Foreground(textColor).Bold(true).Render(summary)}
+
+	for i, entry := range m.plan.Entries {
+		if i >= maxPreviewLines {
+			lines = append(lines, dim.Italic(true).Render(fmt.Sprintf("... and %d more", len(m.plan.Entries)-maxPreviewLines)))
+			break
+		}
+		switch entry.Status {
+		case transfer.ImportNew:
+			lines = append(lines, added.Render("+ "+entry.Host.Alias)+dim.Render("  "+entry.Host.Hostname))
+		case transfer.ImportConflict:
+			lines = append(lines, conflict.Render("! "+entry.Host.Alias)+dim.Render(fmt.Sprintf("  %s, %s has %s",
+				entry.Host.Hostname, renderSourceRef(entry.Existing.Source), entry.Existing.Hostname)))
+		case transfer.ImportSame:
+			lines = append(lines, dim.Render("= "+entry.Host.Alias+"  already exists"))
+		case transfer.ImportInvalid:
+			lines = append(lines, conflict.Render("x "+entry.Host.Alias)+dim.Render("  skipped: "+entry.Reason))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
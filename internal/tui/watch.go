@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"encoding/json"
+	"slices"
+
+	"sshbuddy/internal/config"
+	"sshbuddy/internal/ssh"
+	"sshbuddy/internal/watch"
+	"sshbuddy/pkg/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FilesChangedMsg reports that watched files changed on disk
+type FilesChangedMsg struct {
+	Paths []string
+}
+
+// watchedFiles returns the files whose changes reload the host list: the
+// config file under any of its names, and the SSH config if it is a source.
+// Files the SSH config includes aren't read by the source, so aren't watched.
+func watchedFiles(cfg *models.Config) []string {
+	files, _ := config.ConfigFilePaths()
+	if slices.Contains(config.EnabledSources(cfg), config.SourceSSHConfig) {
+		if path, err := ssh.ConfigFilePath(cfg.SSH.ConfigPath); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// waitForChanges waits for the watcher's next batch of changed files
+func waitForChanges(w *watch.Watcher) tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		paths, ok := <-w.Changes()
+		if !ok {
+			return nil
+		}
+		return FilesChangedMsg{Paths: paths}
+	}
+}
+
+// applyFileChanges reloads what the changed files feed: everything for the
+// config file, only the SSH config source for the SSH config. Unlike
+// reloadSources, hosts stay listed while they reload and keep their ping
+// status, and the selection stays on the same host.
+func (m *Model) applyFileChanges() tea.Cmd {
+	paths := m.pendingChanges
+	m.pendingChanges = nil
+
+	configFiles, _ := config.ConfigFilePaths()
+	configChanged, sshChanged := false, false
+	for _, path := range paths {
+		if slices.Contains(configFiles, path) {
+			configChanged = true
+		} else {
+			sshChanged = true
+		}
+	}
+
+	if configChanged {
+		cfg, err := config.LoadConfigRaw()
+		if err != nil {
+			// Probably saved half-way through an edit; the next save tries again
+			m.config.Warnings = append(m.config.Warnings, "Reloading config failed: "+err.Error())
+			return nil
+		}
+		// Our own saves land here too, and need no reload
+		if !sameConfig(m.config, cfg) {
+			m.config = cfg
+			themeName := cfg.Theme
+			if themeName == "" {
+				themeName = "purple"
+			}
+			ApplyTheme(themeName)
+			if m.watcher != nil {
+				m.watcher.SetFiles(watchedFiles(cfg))
+			}
+			if errs := config.Validate(cfg); models.HasErrors(errs) {
+				m.configErrors = errs
+				m.state = stateConfigError
+			}
+			return m.refreshSources(nil)
+		}
+	}
+
+	if sshChanged {
+		return m.refreshSources([]string{config.SourceSSHConfig})
+	}
+	return nil
+}
+
+// sameConfig reports whether two configs hold the same file contents
+func sameConfig(a, b *models.Config) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aData) == string(bData)
+}
@@ -0,0 +1,155 @@
+// Package watch reports changes to a set of files, such as the config file
+// and ~/.ssh/config, so that edits made in an editor show up without a restart.
+package watch
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"sshbuddy/internal/atomicfile"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDelay is how long a file has to be quiet before a change is reported.
+// Editors often save with several writes or a write and a rename.
+const DefaultDelay = 250 * time.Millisecond
+
+// Watcher watches files and sends the paths that changed, a batch at a time.
+// It watches the directories holding the files rather than the files
+// themselves, so files that are replaced by rename or created later are seen.
+// Files that still hold what this process wrote to them are left out, so
+// saving the config or a refreshed token doesn't trigger a reload.
+type Watcher struct {
+	fs      *fsnotify.Watcher
+	delay   time.Duration
+	changes chan []string
+	done    chan struct{}
+
+	mu    sync.Mutex
+	files map[string]string // Path seen in events -> path reported
+	dirs  map[string]bool
+}
+
+// New starts watching files. Files that don't exist yet are watched for
+// creation, as long as their directory exists.
+func New(files []string, delay time.Duration) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fs:      fs,
+		delay:   delay,
+		changes: make(chan []string),
+		done:    make(chan struct{}),
+		dirs:    make(map[string]bool),
+	}
+	w.SetFiles(files)
+	go w.run()
+	return w, nil
+}
+
+// Changes returns the channel on which changed paths are sent. It is closed
+// when the watcher is closed.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// SetFiles replaces the set of watched files
+func (w *Watcher) SetFiles(files []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.files = make(map[string]string)
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		file = filepath.Clean(file)
+		w.files[file] = file
+		dirs[filepath.Dir(file)] = true
+
+		// A symlinked file, say from a dotfiles repository, changes at its target
+		if target, err := filepath.EvalSymlinks(file); err == nil && target != file {
+			w.files[target] = file
+			dirs[filepath.Dir(target)] = true
+		}
+	}
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.fs.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		// Missing directories are skipped; there is nothing in them to change
+		if err := w.fs.Add(dir); err == nil {
+			w.dirs[dir] = true
+		}
+	}
+}
+
+// Close stops the watcher. Calling it again does nothing.
+func (w *Watcher) Close() error {
+	select {
+	case <-w.done:
+		return nil
+	default:
+	}
+	close(w.done)
+	return w.fs.Close()
+}
+
+// run collects events until the watched files have been quiet for the delay
+func (w *Watcher) run() {
+	defer close(w.changes)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.delay)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			// Permission and access time changes leave the contents alone
+			if !event.Has(fsnotify.Write | fsnotify.Create | fsnotify.Remove | fsnotify.Rename) {
+				continue
+			}
+			w.mu.Lock()
+			file, watched := w.files[filepath.Clean(event.Name)]
+			w.mu.Unlock()
+			if !watched {
+				continue
+			}
+			pending[file] = true
+			timer.Reset(w.delay)
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			var changed []string
+			for file := range pending {
+				if !atomicfile.WrittenHere(file) {
+					changed = append(changed, file)
+				}
+			}
+			pending = make(map[string]bool)
+			if len(changed) == 0 {
+				continue
+			}
+			select {
+			case w.changes <- changed:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
@@ -2,40 +2,208 @@ package models
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
 type Host struct {
-	Alias        string   `json:"alias"`
-	Hostname     string   `json:"hostname"`
-	User         string   `json:"user"`
-	Port         string   `json:"port"`
-	Tags         []string `json:"tags"`
-	IdentityFile string   `json:"identity_file,omitempty"` // Path to SSH key
-	ProxyJump    string   `json:"proxy_jump,omitempty"`    // ProxyJump host
-	Source       string   `json:"source,omitempty"`        // "config" or "manual"
+	Alias         string   `json:"alias"`
+	Hostname      string   `json:"hostname"`
+	User          string   `json:"user"`
+	Port          string   `json:"port"`
+	Tags          []string `json:"tags"`
+	IdentityFile  string   `json:"identityFile,omitempty"`  // Path to SSH key
+	ProxyJump     string   `json:"proxyJump,omitempty"`     // ProxyJump host
+	LocalForwards []string `json:"localForwards,omitempty"` // Local port forwards ("port:host:hostport")
+	Note          string   `json:"note,omitempty"`          // Free-form note shown with the host
+	Source        string   `json:"source,omitempty"`        // "manual", "ssh-config" or "termix:<server>"
+	SourceID      string   `json:"sourceId,omitempty"`      // Stable ID within the source, if not the alias
+	Password      string   `json:"-"`                       // Stored password (Termix only, never persisted)
+	Key           string   `json:"-"`                       // Private key content (Termix only, never persisted)
+	KeyPassphrase string   `json:"-"`                       // Passphrase for Key (Termix only, never persisted)
+	Stale         bool     `json:"-"`                       // Loaded from the offline cache, not the live source
+	Overlaid      bool     `json:"-"`                       // Has local overrides from an overlay
 }
 
 type Config struct {
-	Hosts   []Host        `json:"hosts"`
-	Theme   string        `json:"theme,omitempty"`
-	Sources SourcesConfig `json:"sources"`
-	Termix  TermixConfig  `json:"termix"`
-	SSH     SSHConfig     `json:"ssh"`
+	Version     int           `json:"version"` // Schema version, see config.CurrentConfigVersion
+	Hosts       []Host        `json:"hosts"`
+	Theme       string        `json:"theme,omitempty"`
+	SecretStore string        `json:"secretStore,omitempty"` // "file" or "keyring"; empty picks one
+	Sources     SourcesConfig `json:"sources"`
+	SSH         SSHConfig     `json:"ssh"`
+	Merge       MergeConfig   `json:"merge"`
+
+	// Backups is how many backups of the config file to keep (default 10, -1 for none)
+	Backups int `json:"backups,omitempty"`
+
+	// ManagedConfig controls the ssh_config file generated for plain ssh/scp/rsync
+	ManagedConfig ManagedSSHConfig `json:"managedConfig"`
+
+	// TermixServers are the Termix instances to fetch hosts from, each its own source
+	TermixServers []TermixConfig `json:"termixServers,omitempty"`
+
+	// Inventories are read-only inventory files, each its own source
+	Inventories []InventoryConfig `json:"inventories,omitempty"`
+
+	// Commands are local commands printing hosts as JSON, each its own source
+	Commands []CommandSourceConfig `json:"commands,omitempty"`
+
+	// Terraform lists sets of Terraform state files, each its own source
+	Terraform []TerraformConfig `json:"terraform,omitempty"`
+
+	// Overlays are local changes to hosts from read-only sources
+	Overlays []HostOverlay `json:"overlays,omitempty"`
+
+	// ReturnToList shows the host list again when an SSH session ends, instead of exiting
+	ReturnToList bool `json:"returnToList,omitempty"`
+
+	// Connect controls where sessions open: this terminal, tmux, screen or a new terminal window
+	Connect ConnectConfig `json:"connect,omitzero"`
+
+	// Hooks are commands run before connecting to a host and after the session ends
+	Hooks HooksConfig `json:"hooks,omitzero"`
+
+	// Warnings are non-fatal problems found while loading sources (not persisted)
+	Warnings []string `json:"-"`
 }
 
 type SourcesConfig struct {
 	SSHBuddyEnabled  bool `json:"sshbuddyEnabled"`
 	SSHConfigEnabled bool `json:"sshConfigEnabled"`
-	TermixEnabled    bool `json:"termixEnabled"`
+}
+
+// HostOverlay holds local changes layered on a host from a read-only source.
+// Empty fields leave the source's value alone.
+type HostOverlay struct {
+	Source       string   `json:"source"` // Source name, e.g. "ssh-config" or "termix:prod"
+	ID           string   `json:"id"`     // Host's SourceID, or its alias
+	User         string   `json:"user,omitempty"`
+	Port         string   `json:"port,omitempty"`
+	IdentityFile string   `json:"identityFile,omitempty"`
+	ProxyJump    string   `json:"proxyJump,omitempty"`
+	Tags         []string `json:"tags,omitempty"` // Added to the source's tags
+	Note         string   `json:"note,omitempty"`
+}
+
+// IsEmpty reports whether the overlay changes nothing
+func (o HostOverlay) IsEmpty() bool {
+	return o.User == "" && o.Port == "" && o.IdentityFile == "" && o.ProxyJump == "" && len(o.Tags) == 0 && o.Note == ""
+}
+
+// MergeConfig controls how hosts with the same alias in several sources are combined
+type MergeConfig struct {
+	Precedence []string `json:"precedence,omitempty"` // Source names or kinds, highest first; unlisted sources follow in the default order
+	Fields     bool     `json:"fields,omitempty"`     // Fill empty fields of the winning host from the hidden ones and combine tags
+}
+
+// Connection targets, where a session opens
+const (
+	TargetTerminal   = "terminal"    // The terminal sshbuddy runs in
+	TargetTmuxWindow = "tmux-window" // A new window of the current tmux session
+	TargetTmuxPane   = "tmux-pane"   // A new pane split from the current tmux pane
+	TargetScreen     = "screen"      // A new window of the current screen session
+	TargetEmulator   = "emulator"    // A new terminal emulator window, from ConnectConfig.Emulator
+)
+
+// ConnectTargets lists every connection target
+var ConnectTargets = []string{TargetTerminal, TargetTmuxWindow, TargetTmuxPane, TargetScreen, TargetEmulator}
+
+// ConnectConfig controls where sessions open
+type ConnectConfig struct {
+	Target   string `json:"target,omitempty"`   // Default target (default "terminal")
+	Emulator string `json:"emulator,omitempty"` // Command for the emulator target, e.g. "alacritty -e {command}"
+}
+
+// Validate checks the default target and the emulator command
+func (c ConnectConfig) Validate() []ValidationError {
+	var errors []ValidationError
+	if c.Target != "" && !slices.Contains(ConnectTargets, c.Target) {
+		errors = append(errors, ValidationError{
+			Field:   "Connect",
+			Message: fmt.Sprintf("invalid target '%s' (valid: %s)", c.Target, strings.Join(ConnectTargets, ", ")),
+			Index:   -1,
+		})
+	}
+	if c.Target == TargetEmulator && strings.TrimSpace(c.Emulator) == "" {
+		errors = append(errors, ValidationError{
+			Field:   "Connect",
+			Message: "target is 'emulator' but no emulator command is set",
+			Index:   -1,
+		})
+	}
+	if c.Emulator != "" && !strings.Contains(c.Emulator, "{command}") {
+		errors = append(errors, ValidationError{
+			Field:   "Connect",
+			Message: "emulator command must contain {command}, where the session command goes",
+			Index:   -1,
+		})
+	}
+	return errors
+}
+
+// Hooks are shell commands run around an SSH session. Empty commands are skipped.
+type Hooks struct {
+	PreConnect     string `json:"preConnect,omitempty"`     // Run before ssh; a failure cancels the connection
+	PostDisconnect string `json:"postDisconnect,omitempty"` // Run after ssh exits, whatever its status
+}
+
+// HooksConfig holds the hooks for every host, and for hosts with a tag or alias.
+// All that apply run, in that order.
+type HooksConfig struct {
+	Hooks
+	Tags  map[string]Hooks `json:"tags,omitempty"`  // By tag
+	Hosts map[string]Hooks `json:"hosts,omitempty"` // By alias
 }
 
 type TermixConfig struct {
-	Enabled   bool   `json:"enabled"`
-	BaseURL   string `json:"baseUrl,omitempty"`
-	JWT       string `json:"jwt,omitempty"`
-	JWTExpiry int64  `json:"jwtExpiry,omitempty"`
+	Name        string `json:"name,omitempty"` // Unique server name, shown as the host source
+	Enabled     bool   `json:"enabled"`
+	BaseURL     string `json:"baseUrl,omitempty"`
+	AliasPrefix string `json:"aliasPrefix,omitempty"` // Prepended to every alias from this server
+	JWT         string `json:"jwt,omitempty"`         // Persisted in the secret store; only older config files have it inline
+	JWTExpiry   int64  `json:"jwtExpiry,omitempty"`
+}
+
+type InventoryConfig struct {
+	Name    string `json:"name"` // Unique name, shown as the host source
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+	Format  string `json:"format,omitempty"` // "ansible-ini", "ansible-yaml", "csv", "json" or "yaml"; guessed when empty
+}
+
+type CommandSourceConfig struct {
+	Name     string `json:"name"` // Unique name, shown as the host source
+	Enabled  bool   `json:"enabled"`
+	Command  string `json:"command"`            // Run with the shell; must print a JSON host list
+	CacheTTL string `json:"cacheTtl,omitempty"` // How long output is reused, e.g. "10m" (default 5m, "0" to always run)
+	Timeout  string `json:"timeout,omitempty"`  // Maximum run time, e.g. "30s" (default 30s)
+}
+
+type TerraformConfig struct {
+	Name       string             `json:"name"` // Unique name, shown as the host source
+	Enabled    bool               `json:"enabled"`
+	StateFiles []string           `json:"stateFiles"`         // State file paths; globs are allowed
+	User       string             `json:"user,omitempty"`     // User for every host
+	Address    string             `json:"address,omitempty"`  // "public" (default) or "private"
+	Mappings   []TerraformMapping `json:"mappings,omitempty"` // Extra or replacement resource type mappings
+}
+
+// TerraformMapping tells how to turn resources of one type into hosts. The
+// fields are attribute paths, e.g. "tags.Name" or "network_interface.0.network_ip".
+type TerraformMapping struct {
+	Type      string `json:"type"`                // Resource type, e.g. "aws_instance"
+	Name      string `json:"name,omitempty"`      // Alias; the resource name is used when missing
+	PublicIP  string `json:"publicIp,omitempty"`  // Public address
+	PrivateIP string `json:"privateIp,omitempty"` // Private address
+	Tags      string `json:"tags,omitempty"`      // Map of tags/labels, or a list of tags
 }
 
 type SSHConfig struct {
@@ -43,18 +211,56 @@ type SSHConfig struct {
 	ConfigPath string `json:"configPath,omitempty"`
 }
 
+// ManagedSSHConfig controls the generated ~/.config/sshbuddy/ssh_config
+type ManagedSSHConfig struct {
+	Enabled bool `json:"enabled"`           // Write the file whenever the config is saved
+	Include bool `json:"include,omitempty"` // Also add an Include for it to ~/.ssh/config
+}
+
+// Severity says whether a validation problem stops the config from being used
+type Severity int
+
+const (
+	SeverityError   Severity = iota // Must be fixed before the config is used
+	SeverityWarning                 // Worth fixing, but the config still works
+)
+
 // ValidationError represents a config validation error
 type ValidationError struct {
-	Field   string
-	Message string
-	Index   int // -1 for config-level errors, >= 0 for host-specific errors
+	Field    string
+	Message  string
+	Index    int      // -1 for config-level errors, >= 0 for host-specific errors
+	Severity Severity // SeverityError unless set
+	Line     int      // Line in the config file, if known
+	Column   int      // Column in the config file, if known
 }
 
 func (e ValidationError) Error() string {
+	where := e.Field
 	if e.Index >= 0 {
-		return fmt.Sprintf("Host #%d (%s): %s", e.Index+1, e.Field, e.Message)
+		where = fmt.Sprintf("Host #%d (%s)", e.Index+1, e.Field)
+	}
+	if e.Line > 0 && e.Column > 0 {
+		where += fmt.Sprintf(", line %d, column %d", e.Line, e.Column)
+	} else if e.Line > 0 {
+		where += fmt.Sprintf(", line %d", e.Line)
 	}
-	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	return where + ": " + e.Message
+}
+
+// IsWarning reports whether the problem leaves the config usable
+func (e ValidationError) IsWarning() bool {
+	return e.Severity == SeverityWarning
+}
+
+// HasErrors reports whether errs holds any problem that isn't a warning
+func HasErrors(errs []ValidationError) bool {
+	for _, err := range errs {
+		if !err.IsWarning() {
+			return true
+		}
+	}
+	return false
 }
 
 // Validate checks if a host configuration is valid
@@ -70,13 +276,19 @@ func (h *Host) Validate() []ValidationError {
 		})
 	}
 
-	// Hostname is required
+	// Hostname is required, and must be a host name or an IP address
 	if strings.TrimSpace(h.Hostname) == "" {
 		errors = append(errors, ValidationError{
 			Field:   "Hostname",
 			Message: "hostname is required",
 			Index:   -1,
 		})
+	} else if !ValidHostname(h.Hostname) {
+		errors = append(errors, ValidationError{
+			Field:   "Hostname",
+			Message: fmt.Sprintf("'%s' is not a valid host name or IP address", h.Hostname),
+			Index:   -1,
+		})
 	}
 
 	// User is required
@@ -106,15 +318,270 @@ func (h *Host) Validate() []ValidationError {
 		}
 	}
 
+	// ssh skips keys it can't read or that others can read, and falls back
+	// to other keys, so these don't stop the host from working
+	if h.IdentityFile != "" {
+		if message := checkIdentityFile(h.IdentityFile); message != "" {
+			errors = append(errors, ValidationError{
+				Field:    "IdentityFile",
+				Message:  message,
+				Index:    -1,
+				Severity: SeverityWarning,
+			})
+		}
+	}
+
+	for _, tag := range h.Tags {
+		if !validTag(tag) {
+			errors = append(errors, ValidationError{
+				Field:    "Tags",
+				Message:  fmt.Sprintf("tag '%s' should only contain letters, digits, '-', '_', '.', ':' and '/'", tag),
+				Index:    -1,
+				Severity: SeverityWarning,
+			})
+		}
+	}
+
+	return errors
+}
+
+// ValidHostname reports whether name is an IP address or a syntactically
+// valid host name. Underscores are allowed, as many internal names use them.
+func ValidHostname(name string) bool {
+	// IPv6 may be bracketed and carry a zone, as in [fe80::1%eth0]
+	ip := strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+	if zone := strings.IndexByte(ip, '%'); zone >= 0 {
+		ip = ip[:zone]
+	}
+	if net.ParseIP(ip) != nil {
+		return true
+	}
+
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkIdentityFile returns what is wrong with the private key at path, or ""
+func checkIdentityFile(path string) string {
+	file := path
+	if strings.HasPrefix(file, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		file = filepath.Join(homeDir, file[2:])
+	}
+
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return fmt.Sprintf("identity file %s does not exist", path)
+	}
+	if err != nil {
+		return fmt.Sprintf("identity file %s can't be read: %v", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Sprintf("identity file %s is a directory", path)
+	}
+	// Windows has no Unix permission bits to check
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return fmt.Sprintf("identity file %s is accessible by other users (%04o) and ssh will ignore it; run chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+	return ""
+}
+
+// validTag reports whether tag is non-empty and made of letters, digits and - _ . : /
+func validTag(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, r := range tag {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:/", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks if a Termix server configuration is valid
+func (t *TermixConfig) Validate() []ValidationError {
+	var errors []ValidationError
+
+	// The name ends up in source names, secret keys and cache file names
+	if err := validateSourceName("Termix", "server", t.Name); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if t.Enabled && strings.TrimSpace(t.BaseURL) == "" {
+		errors = append(errors, ValidationError{
+			Field:   "Termix",
+			Message: fmt.Sprintf("server '%s' is enabled but has no baseUrl", t.Name),
+			Index:   -1,
+		})
+	}
+
+	return errors
+}
+
+// Validate checks if an inventory source configuration is valid
+func (i *InventoryConfig) Validate() []ValidationError {
+	var errors []ValidationError
+
+	if err := validateSourceName("Inventory", "inventory", i.Name); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if i.Enabled && strings.TrimSpace(i.Path) == "" {
+		errors = append(errors, ValidationError{
+			Field:   "Inventory",
+			Message: fmt.Sprintf("inventory '%s' is enabled but has no path", i.Name),
+			Index:   -1,
+		})
+	}
+
+	return errors
+}
+
+// Validate checks if a command source configuration is valid
+func (c *CommandSourceConfig) Validate() []ValidationError {
+	var errors []ValidationError
+
+	if err := validateSourceName("Command", "command source", c.Name); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if c.Enabled && strings.TrimSpace(c.Command) == "" {
+		errors = append(errors, ValidationError{
+			Field:   "Command",
+			Message: fmt.Sprintf("command source '%s' is enabled but has no command", c.Name),
+			Index:   -1,
+		})
+	}
+
+	for _, d := range []struct{ name, value string }{{"cacheTtl", c.CacheTTL}, {"timeout", c.Timeout}} {
+		if d.value == "" || d.value == "0" {
+			continue
+		}
+		if duration, err := time.ParseDuration(d.value); err != nil || duration < 0 {
+			errors = append(errors, ValidationError{
+				Field:   "Command",
+				Message: fmt.Sprintf("command source '%s' has an invalid %s '%s' (e.g. 30s, 5m)", c.Name, d.name, d.value),
+				Index:   -1,
+			})
+		}
+	}
+
 	return errors
 }
 
+// Validate checks if a Terraform source configuration is valid
+func (t *TerraformConfig) Validate() []ValidationError {
+	var errors []ValidationError
+
+	if err := validateSourceName("Terraform", "Terraform source", t.Name); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if t.Enabled && len(t.StateFiles) == 0 {
+		errors = append(errors, ValidationError{
+			Field:   "Terraform",
+			Message: fmt.Sprintf("Terraform source '%s' is enabled but has no state files", t.Name),
+			Index:   -1,
+		})
+	}
+
+	if t.Address != "" && t.Address != "public" && t.Address != "private" {
+		errors = append(errors, ValidationError{
+			Field:   "Terraform",
+			Message: fmt.Sprintf("Terraform source '%s' has an invalid address '%s' (must be public or private)", t.Name, t.Address),
+			Index:   -1,
+		})
+	}
+
+	for _, mapping := range t.Mappings {
+		if mapping.Type == "" {
+			errors = append(errors, ValidationError{
+				Field:   "Terraform",
+				Message: fmt.Sprintf("Terraform source '%s' has a mapping without a resource type", t.Name),
+				Index:   -1,
+			})
+		} else if mapping.PublicIP == "" && mapping.PrivateIP == "" {
+			errors = append(errors, ValidationError{
+				Field:   "Terraform",
+				Message: fmt.Sprintf("Terraform source '%s': mapping for %s needs publicIp or privateIp", t.Name, mapping.Type),
+				Index:   -1,
+			})
+		}
+	}
+
+	return errors
+}
+
+// proxyJumpHosts returns the host names in a ProxyJump value, which lists
+// [user@]host[:port] hops or ssh:// URIs separated by commas
+func proxyJumpHosts(proxyJump string) []string {
+	if proxyJump == "" || strings.EqualFold(proxyJump, "none") {
+		return nil
+	}
+	var hosts []string
+	for _, hop := range strings.Split(proxyJump, ",") {
+		hop = strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")
+		if at := strings.LastIndex(hop, "@"); at >= 0 {
+			hop = hop[at+1:]
+		}
+		if host, _, err := net.SplitHostPort(hop); err == nil {
+			hop = host
+		}
+		if hop != "" {
+			hosts = append(hosts, hop)
+		}
+	}
+	return hosts
+}
+
+// validateSourceName checks the name of a source with several instances
+func validateSourceName(field, what, name string) *ValidationError {
+	if name == "" {
+		return &ValidationError{
+			Field:   field,
+			Message: what + " name is required",
+			Index:   -1,
+		}
+	}
+	if strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) >= 0 {
+		return &ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("%s name '%s' may only contain letters, digits, '-' and '_'", what, name),
+			Index:   -1,
+		}
+	}
+	return nil
+}
+
 // Validate checks if the entire config is valid
 func (c *Config) Validate() []ValidationError {
 	var errors []ValidationError
 
 	// Check for duplicate aliases
 	aliasMap := make(map[string]int)
+	aliases := make(map[string]bool)
+	for _, host := range c.Hosts {
+		aliases[strings.TrimSpace(host.Alias)] = true
+	}
 	for i, host := range c.Hosts {
 		alias := strings.TrimSpace(host.Alias)
 		if alias != "" {
@@ -135,8 +602,90 @@ func (c *Config) Validate() []ValidationError {
 			err.Index = i
 			errors = append(errors, err)
 		}
+
+		// A jump host that looks like an alias should be one of ours. It may
+		// still come from ~/.ssh/config, so this is only a warning.
+		for _, hop := range proxyJumpHosts(host.ProxyJump) {
+			if !aliases[hop] && !strings.Contains(hop, ".") && net.ParseIP(hop) == nil && hop != "localhost" {
+				errors = append(errors, ValidationError{
+					Field:    "ProxyJump",
+					Message:  fmt.Sprintf("jump host '%s' is not the alias of another host", hop),
+					Index:    i,
+					Severity: SeverityWarning,
+				})
+			}
+		}
 	}
 
+	// Check Termix servers, which must have unique names
+	serverNames := make(map[string]bool)
+	for _, server := range c.TermixServers {
+		errors = append(errors, server.Validate()...)
+		if server.Name != "" && serverNames[server.Name] {
+			errors = append(errors, ValidationError{
+				Field:   "Termix",
+				Message: fmt.Sprintf("duplicate server name '%s'", server.Name),
+				Index:   -1,
+			})
+		}
+		serverNames[server.Name] = true
+	}
+
+	// Check inventories, which must have unique names
+	inventoryNames := make(map[string]bool)
+	for _, inventory := range c.Inventories {
+		errors = append(errors, inventory.Validate()...)
+		if inventory.Name != "" && inventoryNames[inventory.Name] {
+			errors = append(errors, ValidationError{
+				Field:   "Inventory",
+				Message: fmt.Sprintf("duplicate inventory name '%s'", inventory.Name),
+				Index:   -1,
+			})
+		}
+		inventoryNames[inventory.Name] = true
+	}
+
+	// Check command sources, which must have unique names
+	commandNames := make(map[string]bool)
+	for _, command := range c.Commands {
+		errors = append(errors, command.Validate()...)
+		if command.Name != "" && commandNames[command.Name] {
+			errors = append(errors, ValidationError{
+				Field:   "Command",
+				Message: fmt.Sprintf("duplicate command source name '%s'", command.Name),
+				Index:   -1,
+			})
+		}
+		commandNames[command.Name] = true
+	}
+
+	// Check Terraform sources, which must have unique names
+	terraformNames := make(map[string]bool)
+	for _, tf := range c.Terraform {
+		errors = append(errors, tf.Validate()...)
+		if tf.Name != "" && terraformNames[tf.Name] {
+			errors = append(errors, ValidationError{
+				Field:   "Terraform",
+				Message: fmt.Sprintf("duplicate Terraform source name '%s'", tf.Name),
+				Index:   -1,
+			})
+		}
+		terraformNames[tf.Name] = true
+	}
+
+	// Check overlays, which must name the host they apply to
+	for _, overlay := range c.Overlays {
+		if overlay.Source == "" || overlay.ID == "" {
+			errors = append(errors, ValidationError{
+				Field:   "Overlay",
+				Message: "overlay must have a source and an id",
+				Index:   -1,
+			})
+		}
+	}
+
+	errors = append(errors, c.Connect.Validate()...)
+
 	// Validate theme if provided
 	if c.Theme != "" {
 		validThemes := []string{"purple", "blue", "green", "pink", "amber", "cyan"}